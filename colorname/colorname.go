@@ -0,0 +1,130 @@
+package colorname
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+// CSS selects the built-in CSS/X11 dictionary in Open.
+const CSS = "css"
+
+var ErrEmptyDictionary = errors.New("color dictionary has no colors")
+
+// Entry is a named reference color.
+type Entry struct {
+	Name  string
+	Color color.RGBA
+}
+
+// Match is the dictionary entry nearest to a color and its CIEDE2000 distance.
+type Match struct {
+	Entry
+	Distance float64
+}
+
+// Dictionary finds the perceptually nearest named color. It is safe for concurrent use.
+type Dictionary struct {
+	entries []Entry
+	labs    []colorspace.Lab
+}
+
+// New builds a dictionary from entries.
+func New(entries []Entry) (*Dictionary, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("action=newDictionary err=%v", ErrEmptyDictionary)
+	}
+	d := &Dictionary{
+		entries: entries,
+		labs:    make([]colorspace.Lab, len(entries)),
+	}
+	for i, e := range entries {
+		d.labs[i] = colorspace.ToLab(e.Color)
+	}
+	return d, nil
+}
+
+// CSSDictionary returns the CSS/X11 named colors.
+func CSSDictionary() *Dictionary {
+	d, _ := New(cssColors)
+	return d
+}
+
+// Open returns the CSS dictionary for "css" or an empty name, otherwise loads the file at nameOrPath.
+func Open(nameOrPath string) (*Dictionary, error) {
+	if nameOrPath == "" || strings.EqualFold(nameOrPath, CSS) {
+		return CSSDictionary(), nil
+	}
+	return Load(nameOrPath)
+}
+
+// Load reads a csv file of name,hex rows. A header row, blank lines and lines starting with # are skipped.
+func Load(path string) (*Dictionary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("action=loadDictionary path=%v err=%v", path, err)
+	}
+	defer f.Close()
+	d, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("action=loadDictionary path=%v err=%v", path, err)
+	}
+	return d, nil
+}
+
+// Read parses name,hex csv rows from r, see Load. Errors give the row number but not its content.
+func Read(r io.Reader) (*Dictionary, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var entries []Entry
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("action=readDictionary err=%v", err)
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("action=readDictionary row=%v err=expected name,hex", row)
+		}
+		name := strings.TrimSpace(record[0])
+		c, err := colorspace.ParseHex(record[1])
+		if err != nil {
+			if row == 1 {
+				// header
+				continue
+			}
+			// the row itself is left out, the input may not be meant to be shown
+			return nil, fmt.Errorf("action=readDictionary row=%v err=%v", row, colorspace.ErrInvalidHex)
+		}
+		entries = append(entries, Entry{Name: name, Color: c})
+	}
+	return New(entries)
+}
+
+// Entries returns the dictionary colors in their original order.
+func (d *Dictionary) Entries() []Entry {
+	return d.entries
+}
+
+// Nearest returns the entry with the smallest CIEDE2000 distance to c. Ties go to the earlier entry.
+func (d *Dictionary) Nearest(c color.Color) Match {
+	lab := colorspace.ToLab(c)
+	best := Match{Entry: d.entries[0], Distance: colorspace.DeltaE2000(lab, d.labs[0])}
+	for i := 1; i < len(d.entries); i++ {
+		dist := colorspace.DeltaE2000(lab, d.labs[i])
+		if dist < best.Distance {
+			best = Match{Entry: d.entries[i], Distance: dist}
+		}
+	}
+	return best
+}
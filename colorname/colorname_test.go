@@ -0,0 +1,85 @@
+package colorname
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantNames []string
+		wantErr   bool
+	}{
+		{name: "rows", input: "red,#ff0000\nblue,#0000ff\n", wantNames: []string{"red", "blue"}},
+		{name: "header comments and blank lines", input: "name,hex\n# brand\n\nred, #ff0000\n", wantNames: []string{"red"}},
+		{name: "missing hex", input: "red,#ff0000\nblue\n", wantErr: true},
+		{name: "invalid hex", input: "red,#ff0000\nblue,secret-value\n", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Read(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Read()=%v, want error", d.Entries())
+				}
+				if strings.Contains(err.Error(), "secret-value") {
+					t.Errorf("error %q shows the input", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, e := range d.Entries() {
+				names = append(names, e.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("names=%v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestNearest(t *testing.T) {
+	d, err := Read(strings.NewReader("red,#ff0000\ngreen,#00ff00\nblue,#0000ff\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		c    color.Color
+		want string
+	}{
+		{c: color.RGBA{R: 250, G: 10, B: 5, A: 255}, want: "red"},
+		{c: color.RGBA{R: 0, G: 200, B: 30, A: 255}, want: "green"},
+		{c: color.RGBA{R: 0, G: 0, B: 255, A: 255}, want: "blue"},
+	}
+	for _, tt := range tests {
+		m := d.Nearest(tt.c)
+		if m.Name != tt.want {
+			t.Errorf("Nearest(%v)=%v, want %v", tt.c, m.Name, tt.want)
+		}
+		if m.Distance < 0 {
+			t.Errorf("Nearest(%v) distance=%v, want >= 0", tt.c, m.Distance)
+		}
+	}
+	if m := d.Nearest(color.RGBA{B: 255, A: 255}); m.Distance != 0 {
+		t.Errorf("exact match distance=%v, want 0", m.Distance)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, name := range []string{"", "css", "CSS"} {
+		d, err := Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Entries()) != len(CSSDictionary().Entries()) {
+			t.Errorf("Open(%q) has %v entries, want the css dictionary", name, len(d.Entries()))
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package colorname
+
+import "image/color"
+
+// cssColors are the 148 CSS Color Module Level 4 named colors, which extend the X11 color names.
+// Aliases such as gray/grey share a value; the first listed wins a tie.
+var cssColors = []Entry{
+	{Name: "aliceblue", Color: color.RGBA{R: 0xf0, G: 0xf8, B: 0xff, A: 0xff}},
+	{Name: "antiquewhite", Color: color.RGBA{R: 0xfa, G: 0xeb, B: 0xd7, A: 0xff}},
+	{Name: "aqua", Color: color.RGBA{R: 0x00, G: 0xff, B: 0xff, A: 0xff}},
+	{Name: "aquamarine", Color: color.RGBA{R: 0x7f, G: 0xff, B: 0xd4, A: 0xff}},
+	{Name: "azure", Color: color.RGBA{R: 0xf0, G: 0xff, B: 0xff, A: 0xff}},
+	{Name: "beige", Color: color.RGBA{R: 0xf5, G: 0xf5, B: 0xdc, A: 0xff}},
+	{Name: "bisque", Color: color.RGBA{R: 0xff, G: 0xe4, B: 0xc4, A: 0xff}},
+	{Name: "black", Color: color.RGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff}},
+	{Name: "blanchedalmond", Color: color.RGBA{R: 0xff, G: 0xeb, B: 0xcd, A: 0xff}},
+	{Name: "blue", Color: color.RGBA{R: 0x00, G: 0x00, B: 0xff, A: 0xff}},
+	{Name: "blueviolet", Color: color.RGBA{R: 0x8a, G: 0x2b, B: 0xe2, A: 0xff}},
+	{Name: "brown", Color: color.RGBA{R: 0xa5, G: 0x2a, B: 0x2a, A: 0xff}},
+	{Name: "burlywood", Color: color.RGBA{R: 0xde, G: 0xb8, B: 0x87, A: 0xff}},
+	{Name: "cadetblue", Color: color.RGBA{R: 0x5f, G: 0x9e, B: 0xa0, A: 0xff}},
+	{Name: "chartreuse", Color: color.RGBA{R: 0x7f, G: 0xff, B: 0x00, A: 0xff}},
+	{Name: "chocolate", Color: color.RGBA{R: 0xd2, G: 0x69, B: 0x1e, A: 0xff}},
+	{Name: "coral", Color: color.RGBA{R: 0xff, G: 0x7f, B: 0x50, A: 0xff}},
+	{Name: "cornflowerblue", Color: color.RGBA{R: 0x64, G: 0x95, B: 0xed, A: 0xff}},
+	{Name: "cornsilk", Color: color.RGBA{R: 0xff, G: 0xf8, B: 0xdc, A: 0xff}},
+	{Name: "crimson", Color: color.RGBA{R: 0xdc, G: 0x14, B: 0x3c, A: 0xff}},
+	{Name: "cyan", Color: color.RGBA{R: 0x00, G: 0xff, B: 0xff, A: 0xff}},
+	{Name: "darkblue", Color: color.RGBA{R: 0x00, G: 0x00, B: 0x8b, A: 0xff}},
+	{Name: "darkcyan", Color: color.RGBA{R: 0x00, G: 0x8b, B: 0x8b, A: 0xff}},
+	{Name: "darkgoldenrod", Color: color.RGBA{R: 0xb8, G: 0x86, B: 0x0b, A: 0xff}},
+	{Name: "darkgray", Color: color.RGBA{R: 0xa9, G: 0xa9, B: 0xa9, A: 0xff}},
+	{Name: "darkgreen", Color: color.RGBA{R: 0x00, G: 0x64, B: 0x00, A: 0xff}},
+	{Name: "darkgrey", Color: color.RGBA{R: 0xa9, G: 0xa9, B: 0xa9, A: 0xff}},
+	{Name: "darkkhaki", Color: color.RGBA{R: 0xbd, G: 0xb7, B: 0x6b, A: 0xff}},
+	{Name: "darkmagenta", Color: color.RGBA{R: 0x8b, G: 0x00, B: 0x8b, A: 0xff}},
+	{Name: "darkolivegreen", Color: color.RGBA{R: 0x55, G: 0x6b, B: 0x2f, A: 0xff}},
+	{Name: "darkorange", Color: color.RGBA{R: 0xff, G: 0x8c, B: 0x00, A: 0xff}},
+	{Name: "darkorchid", Color: color.RGBA{R: 0x99, G: 0x32, B: 0xcc, A: 0xff}},
+	{Name: "darkred", Color: color.RGBA{R: 0x8b, G: 0x00, B: 0x00, A: 0xff}},
+	{Name: "darksalmon", Color: color.RGBA{R: 0xe9, G: 0x96, B: 0x7a, A: 0xff}},
+	{Name: "darkseagreen", Color: color.RGBA{R: 0x8f, G: 0xbc, B: 0x8f, A: 0xff}},
+	{Name: "darkslateblue", Color: color.RGBA{R: 0x48, G: 0x3d, B: 0x8b, A: 0xff}},
+	{Name: "darkslategray", Color: color.RGBA{R: 0x2f, G: 0x4f, B: 0x4f, A: 0xff}},
+	{Name: "darkslategrey", Color: color.RGBA{R: 0x2f, G: 0x4f, B: 0x4f, A: 0xff}},
+	{Name: "darkturquoise", Color: color.RGBA{R: 0x00, G: 0xce, B: 0xd1, A: 0xff}},
+	{Name: "darkviolet", Color: color.RGBA{R: 0x94, G: 0x00, B: 0xd3, A: 0xff}},
+	{Name: "deeppink", Color: color.RGBA{R: 0xff, G: 0x14, B: 0x93, A: 0xff}},
+	{Name: "deepskyblue", Color: color.RGBA{R: 0x00, G: 0xbf, B: 0xff, A: 0xff}},
+	{Name: "dimgray", Color: color.RGBA{R: 0x69, G: 0x69, B: 0x69, A: 0xff}},
+	{Name: "dimgrey", Color: color.RGBA{R: 0x69, G: 0x69, B: 0x69, A: 0xff}},
+	{Name: "dodgerblue", Color: color.RGBA{R: 0x1e, G: 0x90, B: 0xff, A: 0xff}},
+	{Name: "firebrick", Color: color.RGBA{R: 0xb2, G: 0x22, B: 0x22, A: 0xff}},
+	{Name: "floralwhite", Color: color.RGBA{R: 0xff, G: 0xfa, B: 0xf0, A: 0xff}},
+	{Name: "forestgreen", Color: color.RGBA{R: 0x22, G: 0x8b, B: 0x22, A: 0xff}},
+	{Name: "fuchsia", Color: color.RGBA{R: 0xff, G: 0x00, B: 0xff, A: 0xff}},
+	{Name: "gainsboro", Color: color.RGBA{R: 0xdc, G: 0xdc, B: 0xdc, A: 0xff}},
+	{Name: "ghostwhite", Color: color.RGBA{R: 0xf8, G: 0xf8, B: 0xff, A: 0xff}},
+	{Name: "gold", Color: color.RGBA{R: 0xff, G: 0xd7, B: 0x00, A: 0xff}},
+	{Name: "goldenrod", Color: color.RGBA{R: 0xda, G: 0xa5, B: 0x20, A: 0xff}},
+	{Name: "gray", Color: color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}},
+	{Name: "green", Color: color.RGBA{R: 0x00, G: 0x80, B: 0x00, A: 0xff}},
+	{Name: "greenyellow", Color: color.RGBA{R: 0xad, G: 0xff, B: 0x2f, A: 0xff}},
+	{Name: "grey", Color: color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}},
+	{Name: "honeydew", Color: color.RGBA{R: 0xf0, G: 0xff, B: 0xf0, A: 0xff}},
+	{Name: "hotpink", Color: color.RGBA{R: 0xff, G: 0x69, B: 0xb4, A: 0xff}},
+	{Name: "indianred", Color: color.RGBA{R: 0xcd, G: 0x5c, B: 0x5c, A: 0xff}},
+	{Name: "indigo", Color: color.RGBA{R: 0x4b, G: 0x00, B: 0x82, A: 0xff}},
+	{Name: "ivory", Color: color.RGBA{R: 0xff, G: 0xff, B: 0xf0, A: 0xff}},
+	{Name: "khaki", Color: color.RGBA{R: 0xf0, G: 0xe6, B: 0x8c, A: 0xff}},
+	{Name: "lavender", Color: color.RGBA{R: 0xe6, G: 0xe6, B: 0xfa, A: 0xff}},
+	{Name: "lavenderblush", Color: color.RGBA{R: 0xff, G: 0xf0, B: 0xf5, A: 0xff}},
+	{Name: "lawngreen", Color: color.RGBA{R: 0x7c, G: 0xfc, B: 0x00, A: 0xff}},
+	{Name: "lemonchiffon", Color: color.RGBA{R: 0xff, G: 0xfa, B: 0xcd, A: 0xff}},
+	{Name: "lightblue", Color: color.RGBA{R: 0xad, G: 0xd8, B: 0xe6, A: 0xff}},
+	{Name: "lightcoral", Color: color.RGBA{R: 0xf0, G: 0x80, B: 0x80, A: 0xff}},
+	{Name: "lightcyan", Color: color.RGBA{R: 0xe0, G: 0xff, B: 0xff, A: 0xff}},
+	{Name: "lightgoldenrodyellow", Color: color.RGBA{R: 0xfa, G: 0xfa, B: 0xd2, A: 0xff}},
+	{Name: "lightgray", Color: color.RGBA{R: 0xd3, G: 0xd3, B: 0xd3, A: 0xff}},
+	{Name: "lightgreen", Color: color.RGBA{R: 0x90, G: 0xee, B: 0x90, A: 0xff}},
+	{Name: "lightgrey", Color: color.RGBA{R: 0xd3, G: 0xd3, B: 0xd3, A: 0xff}},
+	{Name: "lightpink", Color: color.RGBA{R: 0xff, G: 0xb6, B: 0xc1, A: 0xff}},
+	{Name: "lightsalmon", Color: color.RGBA{R: 0xff, G: 0xa0, B: 0x7a, A: 0xff}},
+	{Name: "lightseagreen", Color: color.RGBA{R: 0x20, G: 0xb2, B: 0xaa, A: 0xff}},
+	{Name: "lightskyblue", Color: color.RGBA{R: 0x87, G: 0xce, B: 0xfa, A: 0xff}},
+	{Name: "lightslategray", Color: color.RGBA{R: 0x77, G: 0x88, B: 0x99, A: 0xff}},
+	{Name: "lightslategrey", Color: color.RGBA{R: 0x77, G: 0x88, B: 0x99, A: 0xff}},
+	{Name: "lightsteelblue", Color: color.RGBA{R: 0xb0, G: 0xc4, B: 0xde, A: 0xff}},
+	{Name: "lightyellow", Color: color.RGBA{R: 0xff, G: 0xff, B: 0xe0, A: 0xff}},
+	{Name: "lime", Color: color.RGBA{R: 0x00, G: 0xff, B: 0x00, A: 0xff}},
+	{Name: "limegreen", Color: color.RGBA{R: 0x32, G: 0xcd, B: 0x32, A: 0xff}},
+	{Name: "linen", Color: color.RGBA{R: 0xfa, G: 0xf0, B: 0xe6, A: 0xff}},
+	{Name: "magenta", Color: color.RGBA{R: 0xff, G: 0x00, B: 0xff, A: 0xff}},
+	{Name: "maroon", Color: color.RGBA{R: 0x80, G: 0x00, B: 0x00, A: 0xff}},
+	{Name: "mediumaquamarine", Color: color.RGBA{R: 0x66, G: 0xcd, B: 0xaa, A: 0xff}},
+	{Name: "mediumblue", Color: color.RGBA{R: 0x00, G: 0x00, B: 0xcd, A: 0xff}},
+	{Name: "mediumorchid", Color: color.RGBA{R: 0xba, G: 0x55, B: 0xd3, A: 0xff}},
+	{Name: "mediumpurple", Color: color.RGBA{R: 0x93, G: 0x70, B: 0xdb, A: 0xff}},
+	{Name: "mediumseagreen", Color: color.RGBA{R: 0x3c, G: 0xb3, B: 0x71, A: 0xff}},
+	{Name: "mediumslateblue", Color: color.RGBA{R: 0x7b, G: 0x68, B: 0xee, A: 0xff}},
+	{Name: "mediumspringgreen", Color: color.RGBA{R: 0x00, G: 0xfa, B: 0x9a, A: 0xff}},
+	{Name: "mediumturquoise", Color: color.RGBA{R: 0x48, G: 0xd1, B: 0xcc, A: 0xff}},
+	{Name: "mediumvioletred", Color: color.RGBA{R: 0xc7, G: 0x15, B: 0x85, A: 0xff}},
+	{Name: "midnightblue", Color: color.RGBA{R: 0x19, G: 0x19, B: 0x70, A: 0xff}},
+	{Name: "mintcream", Color: color.RGBA{R: 0xf5, G: 0xff, B: 0xfa, A: 0xff}},
+	{Name: "mistyrose", Color: color.RGBA{R: 0xff, G: 0xe4, B: 0xe1, A: 0xff}},
+	{Name: "moccasin", Color: color.RGBA{R: 0xff, G: 0xe4, B: 0xb5, A: 0xff}},
+	{Name: "navajowhite", Color: color.RGBA{R: 0xff, G: 0xde, B: 0xad, A: 0xff}},
+	{Name: "navy", Color: color.RGBA{R: 0x00, G: 0x00, B: 0x80, A: 0xff}},
+	{Name: "oldlace", Color: color.RGBA{R: 0xfd, G: 0xf5, B: 0xe6, A: 0xff}},
+	{Name: "olive", Color: color.RGBA{R: 0x80, G: 0x80, B: 0x00, A: 0xff}},
+	{Name: "olivedrab", Color: color.RGBA{R: 0x6b, G: 0x8e, B: 0x23, A: 0xff}},
+	{Name: "orange", Color: color.RGBA{R: 0xff, G: 0xa5, B: 0x00, A: 0xff}},
+	{Name: "orangered", Color: color.RGBA{R: 0xff, G: 0x45, B: 0x00, A: 0xff}},
+	{Name: "orchid", Color: color.RGBA{R: 0xda, G: 0x70, B: 0xd6, A: 0xff}},
+	{Name: "palegoldenrod", Color: color.RGBA{R: 0xee, G: 0xe8, B: 0xaa, A: 0xff}},
+	{Name: "palegreen", Color: color.RGBA{R: 0x98, G: 0xfb, B: 0x98, A: 0xff}},
+	{Name: "paleturquoise", Color: color.RGBA{R: 0xaf, G: 0xee, B: 0xee, A: 0xff}},
+	{Name: "palevioletred", Color: color.RGBA{R: 0xdb, G: 0x70, B: 0x93, A: 0xff}},
+	{Name: "papayawhip", Color: color.RGBA{R: 0xff, G: 0xef, B: 0xd5, A: 0xff}},
+	{Name: "peachpuff", Color: color.RGBA{R: 0xff, G: 0xda, B: 0xb9, A: 0xff}},
+	{Name: "peru", Color: color.RGBA{R: 0xcd, G: 0x85, B: 0x3f, A: 0xff}},
+	{Name: "pink", Color: color.RGBA{R: 0xff, G: 0xc0, B: 0xcb, A: 0xff}},
+	{Name: "plum", Color: color.RGBA{R: 0xdd, G: 0xa0, B: 0xdd, A: 0xff}},
+	{Name: "powderblue", Color: color.RGBA{R: 0xb0, G: 0xe0, B: 0xe6, A: 0xff}},
+	{Name: "purple", Color: color.RGBA{R: 0x80, G: 0x00, B: 0x80, A: 0xff}},
+	{Name: "rebeccapurple", Color: color.RGBA{R: 0x66, G: 0x33, B: 0x99, A: 0xff}},
+	{Name: "red", Color: color.RGBA{R: 0xff, G: 0x00, B: 0x00, A: 0xff}},
+	{Name: "rosybrown", Color: color.RGBA{R: 0xbc, G: 0x8f, B: 0x8f, A: 0xff}},
+	{Name: "royalblue", Color: color.RGBA{R: 0x41, G: 0x69, B: 0xe1, A: 0xff}},
+	{Name: "saddlebrown", Color: color.RGBA{R: 0x8b, G: 0x45, B: 0x13, A: 0xff}},
+	{Name: "salmon", Color: color.RGBA{R: 0xfa, G: 0x80, B: 0x72, A: 0xff}},
+	{Name: "sandybrown", Color: color.RGBA{R: 0xf4, G: 0xa4, B: 0x60, A: 0xff}},
+	{Name: "seagreen", Color: color.RGBA{R: 0x2e, G: 0x8b, B: 0x57, A: 0xff}},
+	{Name: "seashell", Color: color.RGBA{R: 0xff, G: 0xf5, B: 0xee, A: 0xff}},
+	{Name: "sienna", Color: color.RGBA{R: 0xa0, G: 0x52, B: 0x2d, A: 0xff}},
+	{Name: "silver", Color: color.RGBA{R: 0xc0, G: 0xc0, B: 0xc0, A: 0xff}},
+	{Name: "skyblue", Color: color.RGBA{R: 0x87, G: 0xce, B: 0xeb, A: 0xff}},
+	{Name: "slateblue", Color: color.RGBA{R: 0x6a, G: 0x5a, B: 0xcd, A: 0xff}},
+	{Name: "slategray", Color: color.RGBA{R: 0x70, G: 0x80, B: 0x90, A: 0xff}},
+	{Name: "slategrey", Color: color.RGBA{R: 0x70, G: 0x80, B: 0x90, A: 0xff}},
+	{Name: "snow", Color: color.RGBA{R: 0xff, G: 0xfa, B: 0xfa, A: 0xff}},
+	{Name: "springgreen", Color: color.RGBA{R: 0x00, G: 0xff, B: 0x7f, A: 0xff}},
+	{Name: "steelblue", Color: color.RGBA{R: 0x46, G: 0x82, B: 0xb4, A: 0xff}},
+	{Name: "tan", Color: color.RGBA{R: 0xd2, G: 0xb4, B: 0x8c, A: 0xff}},
+	{Name: "teal", Color: color.RGBA{R: 0x00, G: 0x80, B: 0x80, A: 0xff}},
+	{Name: "thistle", Color: color.RGBA{R: 0xd8, G: 0xbf, B: 0xd8, A: 0xff}},
+	{Name: "tomato", Color: color.RGBA{R: 0xff, G: 0x63, B: 0x47, A: 0xff}},
+	{Name: "turquoise", Color: color.RGBA{R: 0x40, G: 0xe0, B: 0xd0, A: 0xff}},
+	{Name: "violet", Color: color.RGBA{R: 0xee, G: 0x82, B: 0xee, A: 0xff}},
+	{Name: "wheat", Color: color.RGBA{R: 0xf5, G: 0xde, B: 0xb3, A: 0xff}},
+	{Name: "white", Color: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+	{Name: "whitesmoke", Color: color.RGBA{R: 0xf5, G: 0xf5, B: 0xf5, A: 0xff}},
+	{Name: "yellow", Color: color.RGBA{R: 0xff, G: 0xff, B: 0x00, A: 0xff}},
+	{Name: "yellowgreen", Color: color.RGBA{R: 0x9a, G: 0xcd, B: 0x32, A: 0xff}},
+}
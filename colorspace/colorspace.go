@@ -0,0 +1,304 @@
+package colorspace
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidHex = errors.New("invalid hex color")
+
+// D65 reference white used for CIE XYZ <-> CIELAB.
+const (
+	whiteX = 0.95047
+	whiteY = 1.00000
+	whiteZ = 1.08883
+)
+
+// RGB8 returns the 8-bit sRGB channels of c.
+func RGB8(c color.Color) (uint8, uint8, uint8) {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return rgba.R, rgba.G, rgba.B
+}
+
+// Hex formats c as #rrggbb.
+func Hex(c color.Color) string {
+	r, g, b := RGB8(c)
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// ParseHex parses #rrggbb, rrggbb or #rgb.
+func ParseHex(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("action=parseHex hex=%v err=%v", s, ErrInvalidHex)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("action=parseHex hex=%v err=%v", s, ErrInvalidHex)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+func unit(c color.Color) (float64, float64, float64) {
+	r, g, b := RGB8(c)
+	return float64(r) / 255, float64(g) / 255, float64(b) / 255
+}
+
+// hue returns the hue in degrees [0, 360) shared by HSV and HSL.
+func hue(r, g, b, max, delta float64) float64 {
+	if delta == 0 {
+		return 0
+	}
+	var h float64
+	switch max {
+	case r:
+		h = math.Mod((g-b)/delta, 6)
+	case g:
+		h = (b-r)/delta + 2
+	default:
+		h = (r-g)/delta + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// HSV returns hue in degrees [0, 360), saturation and value in [0, 1].
+func HSV(c color.Color) (float64, float64, float64) {
+	r, g, b := unit(c)
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+	s := float64(0)
+	if max > 0 {
+		s = delta / max
+	}
+	return hue(r, g, b, max, delta), s, max
+}
+
+// HSL returns hue in degrees [0, 360), saturation and lightness in [0, 1].
+func HSL(c color.Color) (float64, float64, float64) {
+	r, g, b := unit(c)
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+	l := (max + min) / 2
+	s := float64(0)
+	if delta > 0 {
+		s = delta / (1 - math.Abs(2*l-1))
+	}
+	return hue(r, g, b, max, delta), s, l
+}
+
+// Lab is a CIELAB color under the D65 illuminant. L is in [0, 100].
+type Lab struct {
+	L float64
+	A float64
+	B float64
+}
+
+// LCh is the cylindrical form of Lab. H is in degrees [0, 360).
+type LCh struct {
+	L float64
+	C float64
+	H float64
+}
+
+func toLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func fromLinear(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func linearRGB(c color.Color) (float64, float64, float64) {
+	r, g, b := unit(c)
+	return toLinear(r), toLinear(g), toLinear(b)
+}
+
+// fromLinearRGB clamps linear sRGB into gamut and encodes it as 8-bit sRGB.
+func fromLinearRGB(r, g, b float64) color.RGBA {
+	to8 := func(v float64) uint8 {
+		v = fromLinear(math.Max(0, math.Min(1, v)))
+		return uint8(math.Round(v * 255))
+	}
+	return color.RGBA{R: to8(r), G: to8(g), B: to8(b), A: 255}
+}
+
+func labF(t float64) float64 {
+	if t > 216.0/24389.0 {
+		return math.Cbrt(t)
+	}
+	return (24389.0/27.0*t + 16) / 116
+}
+
+func labFInv(t float64) float64 {
+	if t3 := t * t * t; t3 > 216.0/24389.0 {
+		return t3
+	}
+	return (116*t - 16) / (24389.0 / 27.0)
+}
+
+// ToLab converts an sRGB color to CIELAB.
+func ToLab(c color.Color) Lab {
+	r, g, b := linearRGB(c)
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / whiteX
+	y := (0.2126729*r + 0.7151522*g + 0.0721750*b) / whiteY
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / whiteZ
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return Lab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+// RGBA converts back to sRGB, clamping out of gamut colors.
+func (l Lab) RGBA() color.RGBA {
+	fy := (l.L + 16) / 116
+	fx := fy + l.A/500
+	fz := fy - l.B/200
+	x := labFInv(fx) * whiteX
+	y := labFInv(fy) * whiteY
+	z := labFInv(fz) * whiteZ
+	r := 3.2404542*x - 1.5371385*y - 0.4985314*z
+	g := -0.9692660*x + 1.8760108*y + 0.0415560*z
+	b := 0.0556434*x - 0.2040259*y + 1.0572252*z
+	return fromLinearRGB(r, g, b)
+}
+
+func (l Lab) LCh() LCh {
+	h := math.Atan2(l.B, l.A) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return LCh{
+		L: l.L,
+		C: math.Hypot(l.A, l.B),
+		H: h,
+	}
+}
+
+// OKLab is Björn Ottosson's perceptual color space. L is in [0, 1].
+type OKLab struct {
+	L float64
+	A float64
+	B float64
+}
+
+// ToOKLab converts an sRGB color to OKLab.
+func ToOKLab(c color.Color) OKLab {
+	r, g, b := linearRGB(c)
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return OKLab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// RGBA converts back to sRGB, clamping out of gamut colors.
+func (o OKLab) RGBA() color.RGBA {
+	l := o.L + 0.3963377774*o.A + 0.2158037573*o.B
+	m := o.L - 0.1055613458*o.A - 0.0638541728*o.B
+	s := o.L - 0.0894841775*o.A - 1.2914855480*o.B
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return fromLinearRGB(
+		4.0767416621*l-3.3077115913*m+0.2309699292*s,
+		-1.2684380046*l+2.6097574011*m-0.3413193965*s,
+		-0.0041960863*l-0.7034186147*m+1.7076147010*s,
+	)
+}
+
+// DeltaE2000 is the CIEDE2000 color difference between two Lab colors.
+// A difference around 1 is the smallest most observers notice.
+func DeltaE2000(x, y Lab) float64 {
+	const pow25to7 = 6103515625.0
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	deg := func(rad float64) float64 { return rad * 180 / math.Pi }
+
+	c1 := math.Hypot(x.A, x.B)
+	c2 := math.Hypot(y.A, y.B)
+	cMean := (c1 + c2) / 2
+	cMean7 := math.Pow(cMean, 7)
+	g := 0.5 * (1 - math.Sqrt(cMean7/(cMean7+pow25to7)))
+	a1 := (1 + g) * x.A
+	a2 := (1 + g) * y.A
+	c1p := math.Hypot(a1, x.B)
+	c2p := math.Hypot(a2, y.B)
+	hp := func(b, a float64) float64 {
+		if a == 0 && b == 0 {
+			return 0
+		}
+		h := deg(math.Atan2(b, a))
+		if h < 0 {
+			h += 360
+		}
+		return h
+	}
+	h1p := hp(x.B, a1)
+	h2p := hp(y.B, a2)
+
+	dLp := y.L - x.L
+	dCp := c2p - c1p
+	var dhp float64
+	if c1p*c2p != 0 {
+		dhp = h2p - h1p
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(c1p*c2p) * math.Sin(rad(dhp/2))
+
+	lMean := (x.L + y.L) / 2
+	cpMean := (c1p + c2p) / 2
+	hpMean := h1p + h2p
+	if c1p*c2p != 0 {
+		if math.Abs(h1p-h2p) > 180 {
+			if hpMean < 360 {
+				hpMean += 360
+			} else {
+				hpMean -= 360
+			}
+		}
+		hpMean /= 2
+	}
+
+	t := 1 - 0.17*math.Cos(rad(hpMean-30)) +
+		0.24*math.Cos(rad(2*hpMean)) +
+		0.32*math.Cos(rad(3*hpMean+6)) -
+		0.20*math.Cos(rad(4*hpMean-63))
+	dTheta := 30 * math.Exp(-math.Pow((hpMean-275)/25, 2))
+	cpMean7 := math.Pow(cpMean, 7)
+	rc := 2 * math.Sqrt(cpMean7/(cpMean7+pow25to7))
+	l50 := (lMean - 50) * (lMean - 50)
+	sl := 1 + 0.015*l50/math.Sqrt(20+l50)
+	sc := 1 + 0.045*cpMean
+	sh := 1 + 0.015*cpMean*t
+	rt := -math.Sin(rad(2*dTheta)) * rc
+
+	dl := dLp / sl
+	dc := dCp / sc
+	dh := dHp / sh
+	return math.Sqrt(dl*dl + dc*dc + dh*dh + rt*dc*dh)
+}
@@ -0,0 +1,168 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		hex     string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{hex: "#ff6600", want: color.RGBA{R: 0xff, G: 0x66, A: 255}},
+		{hex: "0C2238", want: color.RGBA{R: 0x0c, G: 0x22, B: 0x38, A: 255}},
+		{hex: " #f60 ", want: color.RGBA{R: 0xff, G: 0x66, A: 255}},
+		{hex: "", wantErr: true},
+		{hex: "#ff66", wantErr: true},
+		{hex: "#gg0000", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHex(tt.hex)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseHex(%q)=%v, want error", tt.hex, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseHex(%q)=%v, %v, want %v", tt.hex, got, err, tt.want)
+		}
+	}
+}
+
+func TestHSVAndHSL(t *testing.T) {
+	tests := []struct {
+		c   color.RGBA
+		hsv [3]float64
+		hsl [3]float64
+	}{
+		{c: color.RGBA{R: 255, A: 255}, hsv: [3]float64{0, 1, 1}, hsl: [3]float64{0, 1, 0.5}},
+		{c: color.RGBA{G: 255, A: 255}, hsv: [3]float64{120, 1, 1}, hsl: [3]float64{120, 1, 0.5}},
+		{c: color.RGBA{B: 255, A: 255}, hsv: [3]float64{240, 1, 1}, hsl: [3]float64{240, 1, 0.5}},
+		{c: color.RGBA{R: 255, B: 255, A: 255}, hsv: [3]float64{300, 1, 1}, hsl: [3]float64{300, 1, 0.5}},
+		{c: color.RGBA{R: 255, G: 128, A: 255}, hsv: [3]float64{30.118, 1, 1}, hsl: [3]float64{30.118, 1, 0.5}},
+		{c: color.RGBA{R: 128, G: 128, B: 128, A: 255}, hsv: [3]float64{0, 0, 0.502}, hsl: [3]float64{0, 0, 0.502}},
+		{c: color.RGBA{A: 255}, hsv: [3]float64{0, 0, 0}, hsl: [3]float64{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		h, s, v := HSV(tt.c)
+		if !near(h, tt.hsv[0], 1e-3) || !near(s, tt.hsv[1], 1e-3) || !near(v, tt.hsv[2], 1e-3) {
+			t.Errorf("HSV(%v)=%v, %v, %v, want %v", tt.c, h, s, v, tt.hsv)
+		}
+		h, s, l := HSL(tt.c)
+		if !near(h, tt.hsl[0], 1e-3) || !near(s, tt.hsl[1], 1e-3) || !near(l, tt.hsl[2], 1e-3) {
+			t.Errorf("HSL(%v)=%v, %v, %v, want %v", tt.c, h, s, l, tt.hsl)
+		}
+	}
+}
+
+func TestToLab(t *testing.T) {
+	tests := []struct {
+		c    color.RGBA
+		want Lab
+	}{
+		{c: color.RGBA{A: 255}, want: Lab{}},
+		{c: color.RGBA{R: 255, G: 255, B: 255, A: 255}, want: Lab{L: 100}},
+		{c: color.RGBA{R: 128, G: 128, B: 128, A: 255}, want: Lab{L: 53.585}},
+		{c: color.RGBA{R: 255, A: 255}, want: Lab{L: 53.241, A: 80.092, B: 67.203}},
+		{c: color.RGBA{G: 255, A: 255}, want: Lab{L: 87.735, A: -86.183, B: 83.179}},
+		{c: color.RGBA{B: 255, A: 255}, want: Lab{L: 32.297, A: 79.188, B: -107.860}},
+	}
+	for _, tt := range tests {
+		got := ToLab(tt.c)
+		if !near(got.L, tt.want.L, 0.01) || !near(got.A, tt.want.A, 0.01) || !near(got.B, tt.want.B, 0.01) {
+			t.Errorf("ToLab(%v)=%+v, want %+v", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestLCh(t *testing.T) {
+	tests := []struct {
+		lab  Lab
+		want LCh
+	}{
+		{lab: Lab{L: 50, A: 10}, want: LCh{L: 50, C: 10, H: 0}},
+		{lab: Lab{L: 50, B: 10}, want: LCh{L: 50, C: 10, H: 90}},
+		{lab: Lab{L: 50, A: -10}, want: LCh{L: 50, C: 10, H: 180}},
+		{lab: Lab{L: 50, B: -10}, want: LCh{L: 50, C: 10, H: 270}},
+		{lab: Lab{L: 50, A: 3, B: 4}, want: LCh{L: 50, C: 5, H: 53.130}},
+	}
+	for _, tt := range tests {
+		got := tt.lab.LCh()
+		if !near(got.L, tt.want.L, 1e-3) || !near(got.C, tt.want.C, 1e-3) || !near(got.H, tt.want.H, 1e-3) {
+			t.Errorf("%+v.LCh()=%+v, want %+v", tt.lab, got, tt.want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, hex := range []string{"#000000", "#ffffff", "#808080", "#ff0000", "#00ff00", "#0000ff", "#0c2238", "#ff6600", "#7f3fbf"} {
+		c, err := ParseHex(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Hex(c); got != hex {
+			t.Errorf("Hex(ParseHex(%v))=%v", hex, got)
+		}
+		if got := ToLab(c).RGBA(); got != c {
+			t.Errorf("ToLab(%v).RGBA()=%v", hex, Hex(got))
+		}
+		if got := ToOKLab(c).RGBA(); got != c {
+			t.Errorf("ToOKLab(%v).RGBA()=%v", hex, Hex(got))
+		}
+	}
+	// out of gamut colors are clamped
+	if got := (Lab{L: 50, A: 200, B: -200}).RGBA(); got.A != 255 {
+		t.Errorf("out of gamut Lab.RGBA()=%v, want opaque", got)
+	}
+	white := ToOKLab(color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	if !near(white.L, 1, 1e-3) || !near(white.A, 0, 1e-3) || !near(white.B, 0, 1e-3) {
+		t.Errorf("ToOKLab(white)=%+v, want L 1", white)
+	}
+}
+
+// TestDeltaE2000 checks pairs of the CIEDE2000 test data by Sharma, Wu and Dalal.
+func TestDeltaE2000(t *testing.T) {
+	tests := []struct {
+		x, y Lab
+		want float64
+	}{
+		{x: Lab{50, 2.6772, -79.7751}, y: Lab{50, 0, -82.7485}, want: 2.0425},
+		{x: Lab{50, 3.1571, -77.2803}, y: Lab{50, 0, -82.7485}, want: 2.8615},
+		{x: Lab{50, 2.8361, -74.0200}, y: Lab{50, 0, -82.7485}, want: 3.4412},
+		{x: Lab{50, 0, 0}, y: Lab{50, -1, 2}, want: 2.3669},
+		{x: Lab{50, -1, 2}, y: Lab{50, 0, 0}, want: 2.3669},
+		{x: Lab{50, 2.49, -0.001}, y: Lab{50, -2.49, 0.0009}, want: 7.1792},
+		{x: Lab{50, 2.49, -0.001}, y: Lab{50, -2.49, 0.0011}, want: 7.2195},
+		{x: Lab{50, -0.001, 2.49}, y: Lab{50, 0.0009, -2.49}, want: 4.8045},
+		{x: Lab{50, -0.001, 2.49}, y: Lab{50, 0.0011, -2.49}, want: 4.7461},
+		{x: Lab{50, 2.5, 0}, y: Lab{50, 0, -2.5}, want: 4.3065},
+		{x: Lab{50, 2.5, 0}, y: Lab{73, 25, -18}, want: 27.1492},
+		{x: Lab{50, 2.5, 0}, y: Lab{61, -5, 29}, want: 22.8977},
+		{x: Lab{50, 2.5, 0}, y: Lab{56, -27, -3}, want: 31.9030},
+		{x: Lab{50, 2.5, 0}, y: Lab{58, 24, 15}, want: 19.4535},
+		{x: Lab{50, 2.5, 0}, y: Lab{50, 3.1736, 0.5854}, want: 1.0000},
+		{x: Lab{60.2574, -34.0099, 36.2677}, y: Lab{60.4626, -34.1751, 39.4387}, want: 1.2644},
+		{x: Lab{63.0109, -31.0961, -5.8663}, y: Lab{62.8187, -29.7946, -4.0864}, want: 1.2630},
+		{x: Lab{61.2901, 3.7196, -5.3901}, y: Lab{61.4292, 2.2480, -4.9620}, want: 1.8731},
+		{x: Lab{35.0831, -44.1164, 3.7933}, y: Lab{35.0232, -40.0716, 1.5901}, want: 1.8645},
+		{x: Lab{22.7233, 20.0904, -46.6940}, y: Lab{23.0331, 14.9730, -42.5619}, want: 2.0373},
+		{x: Lab{36.4612, 47.8580, 18.3852}, y: Lab{36.2715, 50.5065, 21.2231}, want: 1.4146},
+		{x: Lab{50, 10, 10}, y: Lab{50, 10, 10}, want: 0},
+	}
+	for _, tt := range tests {
+		if got := DeltaE2000(tt.x, tt.y); !near(got, tt.want, 1e-4) {
+			t.Errorf("DeltaE2000(%+v, %+v)=%.4f, want %.4f", tt.x, tt.y, got, tt.want)
+		}
+		if got := DeltaE2000(tt.y, tt.x); !near(got, tt.want, 1e-4) {
+			t.Errorf("DeltaE2000(%+v, %+v)=%.4f, want %.4f", tt.y, tt.x, got, tt.want)
+		}
+	}
+}
@@ -1,15 +1,40 @@
 package destination
 
 import (
+	sharedS3Internal "github.com/kennykarnama/video-color-palette-generator/shared/s3"
+
 	"fmt"
 )
 
+// NoopScheme discards the upload, as does an empty destination URI.
+const NoopScheme = "noop"
+
+func init() {
+	Register(NoopScheme, func(uri string) (Target, error) { return NewNoop(uri), nil })
+	Register(sharedS3Internal.S3Scheme, func(uri string) (Target, error) { return NewS3DestinationFromURI(uri) })
+	// S3 HTTPS URLs and URLs of a custom S3 endpoint
+	RegisterMatcher("s3 https url", sharedS3Internal.IsHTTPURL, func(uri string) (Target, error) { return NewS3DestinationFromURI(uri) })
+}
+
+// GetTarget returns the Target registered for destinationURI, see Register and RegisterMatcher.
+// An empty destinationURI is the noop target.
 func GetTarget(destinationURI string) (Target, error) {
-	if s3Dest, err := NewS3DestinationFromURI(destinationURI); err == nil {
-		return s3Dest, nil
-	}
 	if destinationURI == "" {
 		return NewNoop(""), nil
 	}
-	return nil, fmt.Errorf("GetTarget destURI=%v err=%v", destinationURI, "unknown target")
+	factory := lookup(destinationURI)
+	if factory == nil {
+		scheme := targets.Scheme(destinationURI)
+		reason := fmt.Sprintf("unsupported scheme %q", scheme)
+		if scheme == "" {
+			reason = "missing scheme"
+		}
+		return nil, fmt.Errorf("GetTarget destURI=%v err=%v, %v",
+			destinationURI, reason, targets.Supported())
+	}
+	target, err := factory(destinationURI)
+	if err != nil {
+		return nil, fmt.Errorf("GetTarget destURI=%v err=%v", destinationURI, err)
+	}
+	return target, nil
 }
@@ -0,0 +1,38 @@
+package destination
+
+import (
+	"github.com/kennykarnama/video-color-palette-generator/internal/registry"
+)
+
+// Factory builds the Target of uri.
+type Factory func(uri string) (Target, error)
+
+// Matcher reports whether its factory handles uri.
+type Matcher func(uri string) bool
+
+// targets holds Factory values. URIs without scheme have none.
+var targets = registry.New("")
+
+// Register makes GetTarget use factory for URIs of scheme, case insensitive, replacing any earlier factory
+// of that scheme.
+func Register(scheme string, factory Factory) {
+	targets.Register(scheme, factory)
+}
+
+// RegisterMatcher makes GetTarget use factory for the URIs match accepts, for backends a scheme
+// cannot tell apart, like S3 HTTPS URLs among other HTTPS URLs. Matchers are tried before schemes,
+// the latest registered first. name only shows in errors.
+func RegisterMatcher(name string, match Matcher, factory Factory) {
+	targets.RegisterMatcher(name, match, factory)
+}
+
+// Schemes lists the registered schemes, sorted.
+func Schemes() []string {
+	return targets.Schemes()
+}
+
+// lookup returns the factory of uri, nil when none handles it.
+func lookup(uri string) Factory {
+	factory, _ := targets.Lookup(uri).(Factory)
+	return factory
+}
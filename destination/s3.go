@@ -10,21 +10,28 @@ import (
 )
 
 type S3Destination struct {
-	Data *sharedS3Internal.S3URI
+	Data   *sharedS3Internal.S3URI
+	Client sharedS3Internal.Client
 }
 
+// NewS3DestinationFromURI uses the default S3 client, see NewS3DestinationWithClient.
 func NewS3DestinationFromURI(destinationURI string) (*S3Destination, error) {
-	data, err := sharedS3Internal.ParseURL(destinationURI)
+	return NewS3DestinationWithClient(destinationURI, sharedS3Internal.DefaultClient())
+}
+
+func NewS3DestinationWithClient(destinationURI string, client sharedS3Internal.Client) (*S3Destination, error) {
+	data, err := sharedS3Internal.ParseURLFor(client, destinationURI)
 	if err != nil {
 		return nil, fmt.Errorf("action=newS3SourceFromURI uri=%v err=%v", destinationURI, err)
 	}
 	return &S3Destination {
-		Data: data,
+		Data:   data,
+		Client: client,
 	}, nil
 }
 
 func (s *S3Destination) Upload(ctx context.Context, data io.Reader) error {
-	err  := sharedS3Internal.PutObject(ctx, s.Data.Bucket, s.Data.Key, data)
+	err  := s.Client.PutObject(ctx, s.Data, data)
 	if err != nil {
 		return fmt.Errorf("s3.destination target_bucket=%v key=%v err=%v", s.Data.Bucket, s.Data.Key, err)
 	}
@@ -0,0 +1,71 @@
+package destination
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	sharedS3Internal "github.com/kennykarnama/video-color-palette-generator/shared/s3"
+	"github.com/kennykarnama/video-color-palette-generator/shared/s3/s3test"
+)
+
+func TestS3DestinationUpload(t *testing.T) {
+	client := s3test.NewClient()
+	d, err := NewS3DestinationWithClient("s3://results/runs/result.csv?region=us-east-2", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte("serial,segment\na,1\n")
+	if err := d.Upload(context.Background(), bytes.NewReader(body)); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := client.Object("results", "runs/result.csv")
+	if !ok || !bytes.Equal(got, body) {
+		t.Errorf("stored %q, want %q", got, body)
+	}
+	want := sharedS3Internal.S3URI{Region: "us-east-2", Bucket: "results", Key: "runs/result.csv"}
+	if requests := client.Requests(); len(requests) != 1 || requests[0] != want {
+		t.Errorf("requests=%v, want %v", requests, want)
+	}
+}
+
+func TestS3DestinationUploadError(t *testing.T) {
+	client := s3test.NewClient()
+	client.Err = errors.New("AccessDenied")
+	d, err := NewS3DestinationWithClient("s3://results/result.csv", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Upload(context.Background(), strings.NewReader("x"))
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("err=%v, want the client error", err)
+	}
+	if _, ok := client.Object("results", "result.csv"); ok {
+		t.Errorf("failed upload stored the object")
+	}
+}
+
+func TestGetTargetUsesDefaultS3Client(t *testing.T) {
+	client := s3test.NewClient()
+	prev := sharedS3Internal.DefaultClient()
+	sharedS3Internal.SetDefaultClient(client)
+	defer sharedS3Internal.SetDefaultClient(prev)
+
+	for _, uri := range []string{
+		"s3://results/result.csv",
+		"https://results.s3.eu-west-1.amazonaws.com/result.csv",
+	} {
+		target, err := GetTarget(uri)
+		if err != nil {
+			t.Fatalf("GetTarget(%v) err=%v", uri, err)
+		}
+		if err := target.Upload(context.Background(), strings.NewReader(uri)); err != nil {
+			t.Fatalf("GetTarget(%v).Upload err=%v", uri, err)
+		}
+		if got, _ := client.Object("results", "result.csv"); string(got) != uri {
+			t.Errorf("GetTarget(%v) stored %q", uri, got)
+		}
+	}
+}
@@ -22,8 +22,8 @@ type ffprobe struct {
 	data *goffprobe.ProbeData
 }
 
-func NewFfprobe(mediaPath string) (*ffprobe, error) {
-	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+func NewFfprobe(ctx context.Context, mediaPath string) (*ffprobe, error) {
+	ctx, cancelFn := context.WithTimeout(ctx, 10*time.Second)
 	defer cancelFn()
 
 	probeData, err := goffprobe.ProbeURL(ctx, mediaPath)
@@ -8,8 +8,8 @@ require (
 	github.com/aws/aws-sdk-go v1.43.30
 	github.com/gocarina/gocsv v0.0.0-20220310154401-d4df709ca055
 	github.com/kennykarnama/color-thief v0.0.0-20220328234405-7e3c8f827622
-	github.com/ozankasikci/go-image-merge v0.2.2
 	github.com/satori/go.uuid v1.2.0
+	github.com/xitongsys/parquet-go v1.6.2
 	gocv.io/x/gocv v0.30.0
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/vansante/go-ffprobe.v2 v2.0.3
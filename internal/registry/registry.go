@@ -0,0 +1,96 @@
+// Package registry maps URIs to factories, by scheme or by matcher, for the source and destination registries.
+package registry
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type matcherEntry struct {
+	name    string
+	match   func(uri string) bool
+	factory interface{}
+}
+
+// Registry holds factories of one kind, which the caller type asserts after Lookup.
+type Registry struct {
+	defaultScheme string
+
+	mu       sync.RWMutex
+	schemes  map[string]interface{}
+	matchers []matcherEntry
+}
+
+// New returns an empty registry. URIs without scheme have defaultScheme, which may be empty.
+func New(defaultScheme string) *Registry {
+	return &Registry{defaultScheme: defaultScheme, schemes: map[string]interface{}{}}
+}
+
+// Register sets the factory of scheme, case insensitive, replacing any earlier one.
+func (r *Registry) Register(scheme string, factory interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemes[strings.ToLower(scheme)] = factory
+}
+
+// RegisterMatcher adds a factory for the URIs match accepts. Matchers are tried before schemes,
+// the latest registered first. name only shows in errors.
+func (r *Registry) RegisterMatcher(name string, match func(uri string) bool, factory interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.matchers = append(r.matchers, matcherEntry{name: name, match: match, factory: factory})
+}
+
+// Schemes lists the registered schemes, sorted.
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.schemes))
+	for scheme := range r.schemes {
+		names = append(names, scheme)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the factory of uri, nil when none handles it.
+func (r *Registry) Lookup(uri string) interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.matchers) - 1; i >= 0; i-- {
+		if r.matchers[i].match(uri) {
+			return r.matchers[i].factory
+		}
+	}
+	return r.schemes[r.Scheme(uri)]
+}
+
+// MatcherNames lists the matchers in registration order.
+func (r *Registry) MatcherNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for _, m := range r.matchers {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// Supported describes the registered schemes and matchers for errors.
+func (r *Registry) Supported() string {
+	supported := "supported schemes: " + strings.Join(r.Schemes(), ", ")
+	if names := r.MatcherNames(); len(names) > 0 {
+		supported += ", matchers: " + strings.Join(names, ", ")
+	}
+	return supported
+}
+
+// Scheme returns the lower case scheme of uri, the default scheme without one.
+func (r *Registry) Scheme(uri string) string {
+	i := strings.Index(uri, "://")
+	if i < 0 {
+		return r.defaultScheme
+	}
+	return strings.ToLower(uri[:i])
+}
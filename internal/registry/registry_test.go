@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegistryLookup(t *testing.T) {
+	r := New("file")
+	r.Register("file", "local")
+	r.Register("HTTPS", "http")
+	r.Register("s3", "old s3")
+	r.Register("s3", "s3")
+	r.RegisterMatcher("cdn", func(uri string) bool { return strings.HasPrefix(uri, "https://cdn.") }, "cdn")
+	r.RegisterMatcher("cdn videos", func(uri string) bool { return strings.HasPrefix(uri, "https://cdn.example.com/videos/") }, "cdn videos")
+
+	tests := []struct {
+		uri  string
+		want interface{}
+	}{
+		{uri: "/videos/a.mp4", want: "local"},
+		{uri: "file:///videos/a.mp4", want: "local"},
+		{uri: "S3://bucket/a.mp4", want: "s3"},
+		{uri: "https://example.com/a.mp4", want: "http"},
+		{uri: "https://cdn.example.com/a.mp4", want: "cdn"},
+		{uri: "https://cdn.example.com/videos/a.mp4", want: "cdn videos"},
+		{uri: "ftp://example.com/a.mp4", want: nil},
+	}
+	for _, tt := range tests {
+		if got := r.Lookup(tt.uri); got != tt.want {
+			t.Errorf("Lookup(%v)=%v, want %v", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestRegistryNames(t *testing.T) {
+	r := New("")
+	if got := r.Lookup("/videos/a.mp4"); got != nil {
+		t.Errorf("Lookup of a plain path=%v, want nil without default scheme", got)
+	}
+	if got, want := r.Supported(), "supported schemes: "; got != want {
+		t.Errorf("Supported()=%q, want %q", got, want)
+	}
+
+	r.Register("s3", 1)
+	r.Register("noop", 2)
+	r.RegisterMatcher("b", func(string) bool { return false }, 3)
+	r.RegisterMatcher("a", func(string) bool { return false }, 4)
+	if got, want := r.Schemes(), []string{"noop", "s3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Schemes()=%v, want %v", got, want)
+	}
+	if got, want := r.MatcherNames(), []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MatcherNames()=%v, want %v", got, want)
+	}
+	if got, want := r.Supported(), "supported schemes: noop, s3, matchers: b, a"; got != want {
+		t.Errorf("Supported()=%q, want %q", got, want)
+	}
+}
+
+func TestRegistryScheme(t *testing.T) {
+	tests := []struct {
+		defaultScheme string
+		uri           string
+		want          string
+	}{
+		{defaultScheme: "file", uri: "/videos/a.mp4", want: "file"},
+		{defaultScheme: "", uri: "videos/a.mp4", want: ""},
+		{defaultScheme: "file", uri: "HTTPS://example.com", want: "https"},
+		{defaultScheme: "file", uri: "s3://bucket/key", want: "s3"},
+	}
+	for _, tt := range tests {
+		if got := New(tt.defaultScheme).Scheme(tt.uri); got != tt.want {
+			t.Errorf("Scheme(%v)=%v, want %v", tt.uri, got, tt.want)
+		}
+	}
+}
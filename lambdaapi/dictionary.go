@@ -0,0 +1,57 @@
+package lambdaapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorname"
+	"github.com/kennykarnama/video-color-palette-generator/source"
+)
+
+// dictionaryMaxBytes bounds color dictionaries fetched over HTTP.
+const dictionaryMaxBytes = 1 << 20
+
+// dictionarySchemes are the URI schemes color dictionaries are fetched from. Local files are not
+// readable from a request.
+var dictionarySchemes = []string{"http", "https", "s3"}
+
+// loadDictionary reads the name,hex rows of a request field, given inline or as an http(s) or s3 URL.
+func loadDictionary(ctx context.Context, field, value string) (*colorname.Dictionary, error) {
+	i := strings.Index(value, "://")
+	if i < 0 {
+		d, err := colorname.Read(strings.NewReader(value))
+		if err != nil {
+			return nil, fmt.Errorf("action=loadDictionary field=%v err=%v, expected name,hex rows or an %v url", field, err, strings.Join(dictionarySchemes, ", "))
+		}
+		return d, nil
+	}
+	scheme := strings.ToLower(value[:i])
+	allowed := false
+	for _, s := range dictionarySchemes {
+		allowed = allowed || s == scheme
+	}
+	if !allowed {
+		return nil, fmt.Errorf("action=loadDictionary field=%v err=unsupported scheme %q, expected name,hex rows or an %v url", field, scheme, strings.Join(dictionarySchemes, ", "))
+	}
+
+	provider, err := source.GetProvider(value)
+	if err != nil {
+		return nil, fmt.Errorf("action=loadDictionary field=%v err=%v", field, err)
+	}
+	if httpSource, ok := provider.(*source.HTTPSource); ok {
+		// served as text/csv or text/plain, not as a video
+		httpSource.AllowedContentTypes = nil
+		httpSource.MaxBytes = dictionaryMaxBytes
+	}
+	localURI, err := provider.LocalURI(ctx, value)
+	if err != nil {
+		return nil, fmt.Errorf("action=loadDictionary field=%v err=%v", field, err)
+	}
+	defer provider.Cleanup(localURI)
+	d, err := colorname.Load(localURI)
+	if err != nil {
+		return nil, fmt.Errorf("action=loadDictionary field=%v uri=%v err=%v", field, value, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,68 @@
+package lambdaapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDictionary(t *testing.T) {
+	os.Setenv("HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS", "true")
+	defer os.Unsetenv("HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/names.csv":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte("name,hex\nred,#ff0000\nblue,#0000ff\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// a file of the lambda itself, which a request must not be able to read
+	secret := filepath.Join(t.TempDir(), "secret.csv")
+	if err := ioutil.WriteFile(secret, []byte("token,secret-value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		value       string
+		wantEntries int
+		wantErr     bool
+	}{
+		{name: "inline", value: "red,#ff0000\ngreen,#00ff00\nblue,#0000ff", wantEntries: 3},
+		{name: "http", value: srv.URL + "/names.csv", wantEntries: 2},
+		{name: "http not found", value: srv.URL + "/missing.csv", wantErr: true},
+		{name: "local path", value: secret, wantErr: true},
+		{name: "file url", value: "file://" + secret, wantErr: true},
+		{name: "unknown scheme", value: "ftp://example.com/names.csv", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := loadDictionary(context.Background(), "colorNames", tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("loadDictionary()=%v entries, want error", len(d.Entries()))
+				}
+				if strings.Contains(err.Error(), "secret-value") {
+					t.Errorf("error %q shows the file content", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(d.Entries()); got != tt.wantEntries {
+				t.Errorf("entries=%v, want %v", got, tt.wantEntries)
+			}
+		})
+	}
+}
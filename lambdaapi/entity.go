@@ -1,16 +1,45 @@
 package lambdaapi
 
+import (
+	"github.com/kennykarnama/video-color-palette-generator/processor"
+)
+
 type ColorPaletteGenerationRequest struct {
 	SourceURL      string `json:"sourceURL"`
 	SourceSerial   string `json:"sourceSerial"`
+	StreamSource   bool `json:"streamSource"`
+	SourceMaxBytes int64 `json:"sourceMaxBytes"`
 	PeriodSeconds  float64 `json:"periodSeconds"`
 	PaletteSize    int `json:"paletteSize"`
 	FunctionType   int `json:"functionType"`
+	Extractor      string `json:"extractor"`
+	ColorSpace     string `json:"colorSpace"`
+	ColorNames     string `json:"colorNames"`
+	BrandPalette   string `json:"brandPalette"`
+	// BrandMaxDeltaE and BrandMinScore take the processor defaults when missing, 0 is a valid value
+	BrandMaxDeltaE *float64 `json:"brandMaxDeltaE"`
+	BrandMinScore  *float64 `json:"brandMinScore"`
+	Workers        int `json:"workers"`
+	Segmentation   string `json:"segmentation"`
+	SceneThreshold float64 `json:"sceneThreshold"`
+	MinSceneDuration float64 `json:"minSceneDuration"`
+	FramesPerSegment int `json:"framesPerSegment"`
+	ScaleFactor    float64 `json:"scaleFactor"`
+	MaxPixels      int `json:"maxPixels"`
+	TargetLongEdge int `json:"targetLongEdge"`
+	Interpolation  string `json:"interpolation"`
 	DestinationURI string `json:"destinationURI"`
+	SummaryDestinationURI string `json:"summaryDestinationURI"`
+	Format         string `json:"format"`
+	ReturnResult   bool `json:"returnResult"`
 }
 
 type ErrorResponse struct {
 	ErrorMessage string `json:"errorMessage"`
 }
 
-type GenericResponse struct {}
+type GenericResponse struct {
+	Summary *processor.SegmentRecord `json:"summary,omitempty"`
+	// Result is the full output when returnResult is set: a json document for the json format, text otherwise.
+	Result interface{} `json:"result,omitempty"`
+}
@@ -3,25 +3,21 @@ package lambdaapi
 import (
  	"github.com/aws/aws-lambda-go/events"
 
-	"github.com/kennykarnama/video-color-palette-generator/source"
 	"github.com/kennykarnama/video-color-palette-generator/processor"
-	"github.com/kennykarnama/video-color-palette-generator/destination"
 
 	"encoding/json"
+	"errors"
 	"net/http"
 	"fmt"
 	"context"
-	"time"
-	"os"
-	"log"
 )
 
-func Handler(req events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+func Handler(ctx context.Context, req events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	switch req.HTTPMethod {
 		case "GET":
 			return GetHandler(req)
 		case "POST":
-			return PostHandler(req)
+			return PostHandler(ctx, req)
 		default:
 			return apiResponse(http.StatusInternalServerError, ErrorResponse{
 				ErrorMessage: fmt.Errorf("unsupported HTTP method").Error(),
@@ -33,7 +29,7 @@ func GetHandler(req events.APIGatewayProxyRequest) (*events.APIGatewayProxyRespo
 	return apiResponse(http.StatusOK, "OK")
 }
 
-func PostHandler(req events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+func PostHandler(ctx context.Context, req events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var paletteGenReq ColorPaletteGenerationRequest
 	if err := json.Unmarshal([]byte(req.Body), &paletteGenReq); err != nil {
 		return apiResponse(http.StatusInternalServerError, ErrorResponse{
@@ -41,63 +37,17 @@ func PostHandler(req events.APIGatewayProxyRequest) (*events.APIGatewayProxyResp
 		})
 	}
 
-	sourceProvider, err := source.GetProvider(paletteGenReq.SourceURL)
+	resp, err := generatePalette(ctx, paletteGenReq)
 	if err != nil {
-		return apiResponse(http.StatusInternalServerError, ErrorResponse{
-			ErrorMessage: err.Error(),
-		})
-	}
-	// parse
-	localURI, err := sourceProvider.LocalURI(context.Background(), paletteGenReq.SourceURL)
-	if err != nil {
-		return apiResponse(http.StatusInternalServerError, ErrorResponse{
-			ErrorMessage: err.Error(),
-		})
-	}
-	csvOut := fmt.Sprintf("/tmp/%v.csv", time.Now().UTC().Unix())
-	param := processor.Parameter{}
-	param.InputFile = localURI
-	param.InputSerial = paletteGenReq.SourceSerial
-	param.PeriodDuration = paletteGenReq.PeriodSeconds
-	param.PaletteSize = paletteGenReq.PaletteSize
-	param.FunctionType = paletteGenReq.FunctionType
-	param.CsvResult = csvOut
-
-	defer func() {
-		for _, f := range []string{csvOut, localURI} {
-			log.Printf("Remove file: %v", f)
-			os.Remove(f)
+		status := http.StatusInternalServerError
+		if errors.Is(err, processor.ErrCanceled) {
+			status = http.StatusGatewayTimeout
 		}
-	}()
-
-	err = processor.Run(param)
-	if err != nil {
-		return apiResponse(http.StatusInternalServerError, ErrorResponse{
-			ErrorMessage: err.Error(),
-		})
-	}
-	// upload to destination source
-	destinationHandler, err := destination.GetTarget(paletteGenReq.DestinationURI)
-	if err != nil {
-		return apiResponse(http.StatusInternalServerError, ErrorResponse{
-			ErrorMessage: err.Error(),
-		})
-	}
-	csvFile, err := os.Open(csvOut)
-	if err != nil {
-		return apiResponse(http.StatusInternalServerError, ErrorResponse{
-			ErrorMessage: err.Error(),
-		})
-	}
-	defer csvFile.Close()
-
-	err = destinationHandler.Upload(context.Background(), csvFile) 
-	if err != nil {
-		return apiResponse(http.StatusInternalServerError, ErrorResponse{
+		return apiResponse(status, ErrorResponse{
 			ErrorMessage: err.Error(),
 		})
 	}
-	return apiResponse(http.StatusOK, struct{}{})
+	return apiResponse(http.StatusOK, resp)
 }
 
 func apiResponse(status int, body interface{}) (*events.APIGatewayProxyResponse, error) {
@@ -0,0 +1,176 @@
+package lambdaapi
+
+import (
+	"github.com/kennykarnama/video-color-palette-generator/colorname"
+	"github.com/kennykarnama/video-color-palette-generator/destination"
+	"github.com/kennykarnama/video-color-palette-generator/processor"
+	"github.com/kennykarnama/video-color-palette-generator/source"
+
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// cleanupMargin is reserved before the lambda deadline so a canceled run can still remove its files and respond.
+const cleanupMargin = 2 * time.Second
+
+// generatePalette downloads the source video, extracts its palettes and uploads them to the destination
+// in the requested format. The response carries the whole-video summary palette.
+func generatePalette(ctx context.Context, paletteGenReq ColorPaletteGenerationRequest) (*GenericResponse, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-cleanupMargin))
+		defer cancel()
+	}
+
+	opts := processor.Options{}
+	opts.InputSerial = paletteGenReq.SourceSerial
+	opts.PeriodDuration = paletteGenReq.PeriodSeconds
+	opts.PaletteSize = paletteGenReq.PaletteSize
+	opts.FunctionType = paletteGenReq.FunctionType
+	opts.Extractor = paletteGenReq.Extractor
+	opts.ColorSpace = paletteGenReq.ColorSpace
+	opts.BrandMaxDeltaE = paletteGenReq.BrandMaxDeltaE
+	opts.BrandMinScore = paletteGenReq.BrandMinScore
+	opts.Workers = paletteGenReq.Workers
+	opts.Segmentation = paletteGenReq.Segmentation
+	opts.SceneThreshold = paletteGenReq.SceneThreshold
+	opts.MinSceneDuration = paletteGenReq.MinSceneDuration
+	opts.FramesPerSegment = paletteGenReq.FramesPerSegment
+	opts.ScaleFactor = paletteGenReq.ScaleFactor
+	opts.MaxPixels = paletteGenReq.MaxPixels
+	opts.TargetLongEdge = paletteGenReq.TargetLongEdge
+	opts.Interpolation = paletteGenReq.Interpolation
+
+	// fail on invalid options before downloading the source
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := processor.ValidateFormat(paletteGenReq.Format); err != nil {
+		return nil, err
+	}
+	// color names and brand palettes are never read from the lambda's own files
+	if names := paletteGenReq.ColorNames; names != "" && !strings.EqualFold(names, colorname.CSS) {
+		dictionary, err := loadDictionary(ctx, "colorNames", names)
+		if err != nil {
+			return nil, canceledError(ctx, 0, err)
+		}
+		opts.ColorNamesDictionary = dictionary
+	}
+	if paletteGenReq.BrandPalette != "" {
+		dictionary, err := loadDictionary(ctx, "brandPalette", paletteGenReq.BrandPalette)
+		if err != nil {
+			return nil, canceledError(ctx, 0, err)
+		}
+		opts.BrandPaletteDictionary = dictionary
+	}
+	summaryBuilder, err := processor.NewSummaryBuilder(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceProvider, err := source.GetProvider(paletteGenReq.SourceURL)
+	if err != nil {
+		return nil, err
+	}
+	if httpSource, ok := sourceProvider.(*source.HTTPSource); ok {
+		httpSource.Stream = paletteGenReq.StreamSource
+		httpSource.MaxBytes = paletteGenReq.SourceMaxBytes
+	}
+	// parse
+	localURI, err := sourceProvider.LocalURI(ctx, paletteGenReq.SourceURL)
+	if err != nil {
+		return nil, canceledError(ctx, 0, err)
+	}
+	defer func() {
+		if err := sourceProvider.Cleanup(localURI); err != nil {
+			log.Printf("action=cleanup localURI=%v err=%v", localURI, err)
+		}
+	}()
+
+	var out bytes.Buffer
+	sink, err := processor.NewSink(paletteGenReq.Format, &out)
+	if err != nil {
+		return nil, err
+	}
+	var lastSegment int
+	err = processor.ExtractFunc(ctx, localURI, opts, func(segment *processor.Segment) error {
+		lastSegment = segment.Number
+		summaryBuilder.Add(segment)
+		return sink.Write(segment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = sink.Close()
+	if err != nil {
+		return nil, err
+	}
+	summary, err := summaryBuilder.Summary()
+	if err != nil {
+		return nil, err
+	}
+
+	// upload to destination source
+	destinationHandler, err := destination.GetTarget(paletteGenReq.DestinationURI)
+	if err != nil {
+		return nil, err
+	}
+	resp := &GenericResponse{}
+	if summary != nil {
+		resp.Summary = summary.Record()
+	}
+	if paletteGenReq.ReturnResult {
+		switch {
+		case strings.EqualFold(paletteGenReq.Format, processor.FormatJSON):
+			resp.Result = json.RawMessage(out.Bytes())
+		case processor.AppendableFormat(paletteGenReq.Format):
+			resp.Result = out.String()
+		default:
+			// binary formats are base64 encoded by encoding/json
+			resp.Result = out.Bytes()
+		}
+	}
+
+	err = destinationHandler.Upload(ctx, &out)
+	if err != nil {
+		return nil, canceledError(ctx, lastSegment, err)
+	}
+
+	if paletteGenReq.SummaryDestinationURI != "" {
+		summaryHandler, err := destination.GetTarget(paletteGenReq.SummaryDestinationURI)
+		if err != nil {
+			return nil, err
+		}
+		var summaryOut bytes.Buffer
+		summarySink, err := processor.NewSink(paletteGenReq.Format, &summaryOut)
+		if err == nil && summary != nil {
+			err = summarySink.Write(summary)
+		}
+		if err == nil {
+			err = summarySink.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = summaryHandler.Upload(ctx, &summaryOut)
+		if err != nil {
+			return nil, canceledError(ctx, lastSegment, err)
+		}
+	}
+	return resp, nil
+}
+
+// canceledError turns err into a *processor.CanceledError once ctx is done, so a deadline passing
+// while the source downloads or the result uploads is answered like one passing during extraction.
+func canceledError(ctx context.Context, segment int, err error) error {
+	if ctx.Err() == nil || errors.Is(err, processor.ErrCanceled) {
+		return err
+	}
+	log.Printf("action=generatePalette.canceled segment=%v err=%v", segment, err)
+	return &processor.CanceledError{Segment: segment, Err: ctx.Err()}
+}
@@ -0,0 +1,107 @@
+package lambdaapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestPostHandlerStatus(t *testing.T) {
+	// the test server listens on loopback
+	os.Setenv("HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS", "true")
+	defer os.Unsetenv("HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS")
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		timeout    time.Duration
+		wantStatus int
+	}{
+		{
+			name: "deadline while downloading",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			},
+			timeout:    cleanupMargin + 100*time.Millisecond,
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "source not found",
+			handler:    http.NotFound,
+			timeout:    cleanupMargin + 5*time.Second,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			body, err := json.Marshal(ColorPaletteGenerationRequest{
+				SourceURL:     srv.URL + "/video.mp4",
+				PeriodSeconds: 10,
+				PaletteSize:   5,
+				Format:        "csv",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			resp, err := PostHandler(ctx, events.APIGatewayProxyRequest{Body: string(body)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status=%v body=%v, want %v", resp.StatusCode, resp.Body, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPostHandlerValidatesBeforeDownload(t *testing.T) {
+	os.Setenv("HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS", "true")
+	defer os.Unsetenv("HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS")
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	for _, req := range []ColorPaletteGenerationRequest{
+		{PeriodSeconds: 10, PaletteSize: 0},
+		{PeriodSeconds: 10, PaletteSize: 5, ScaleFactor: 2},
+		{PeriodSeconds: 10, PaletteSize: 5, Segmentation: "shots"},
+		{PeriodSeconds: 10, PaletteSize: 5, SceneThreshold: 3},
+		{PeriodSeconds: 10, PaletteSize: 5, Format: "xml"},
+		{PeriodSeconds: 10, PaletteSize: 5, ColorNames: "/etc/passwd"},
+		{PeriodSeconds: 10, PaletteSize: 5, BrandPalette: "file:///etc/passwd"},
+	} {
+		req.SourceURL = srv.URL + "/video.mp4"
+		body, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := PostHandler(context.Background(), events.APIGatewayProxyRequest{Body: string(body)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Errorf("%+v: status=%v, want %v", req, resp.StatusCode, http.StatusInternalServerError)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("invalid requests fetched the source %v times", requests)
+	}
+}
@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/alexflint/go-arg"
 	"github.com/aws/aws-lambda-go/lambda"
 
 	"github.com/kennykarnama/video-color-palette-generator/processor"
 	"github.com/kennykarnama/video-color-palette-generator/lambdaapi"
+	"github.com/kennykarnama/video-color-palette-generator/similarity"
 )
 
 var args struct {
 	ScriptCmd *processor.Parameter `arg:"subcommand:script"`
 	LambdaCmd *LambdaArgs `arg:"subcommand:lambda"`
+	CompareCmd *similarity.CompareParameter `arg:"subcommand:compare" help:"palette distance between result files, per segment and overall"`
+	SearchCmd *similarity.SearchParameter `arg:"subcommand:search" help:"rank stored results by similarity to a query video"`
 }
 
 type LambdaArgs struct{}
@@ -29,9 +36,22 @@ func main() {
 	if args.LambdaCmd != nil {
 		log.Printf("Running as lambda")
 		lambda.Start(lambdaapi.Handler)
+	} else if args.CompareCmd != nil {
+		err := similarity.RunCompare(*args.CompareCmd)
+		if err != nil {
+			log.Fatalf("err=%v", err)
+		}
+	} else if args.SearchCmd != nil {
+		err := similarity.RunSearch(*args.SearchCmd)
+		if err != nil {
+			log.Fatalf("err=%v", err)
+		}
 	}else {
 		log.Printf("Running as script")
-		err := processor.Run(*args.ScriptCmd)
+		// stop frame decoding cleanly on Ctrl-C or SIGTERM
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err := processor.Run(ctx, *args.ScriptCmd)
 		if err != nil {
 			log.Fatalf("err=%v", err)
 		}
@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorname"
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+const (
+	// DefaultBrandMaxDeltaE is the CIEDE2000 distance up to which a color counts as on brand.
+	DefaultBrandMaxDeltaE = 10
+	// DefaultBrandMinScore is the compliance score below which a segment violates the brand palette.
+	DefaultBrandMinScore = 0.8
+)
+
+// BrandMatch is the brand color nearest to a palette color.
+type BrandMatch struct {
+	Name   string
+	Hex    string
+	DeltaE float64
+	// Compliant is set when DeltaE is within the allowed distance.
+	Compliant bool
+}
+
+// BrandCompliance summarizes how well a segment palette follows the brand palette.
+type BrandCompliance struct {
+	// Score is the pixel share of the palette covered by compliant colors, from 0 to 1.
+	Score     float64
+	Violation bool
+}
+
+// brandChecker compares palettes against a reference brand palette.
+type brandChecker struct {
+	palette   *colorname.Dictionary
+	maxDeltaE float64
+	minScore  float64
+}
+
+// newBrandChecker uses opts.BrandPaletteDictionary or loads opts.BrandPalette. It returns nil when no
+// brand palette is configured.
+func newBrandChecker(opts Options) (*brandChecker, error) {
+	maxDeltaE, minScore, err := brandThresholds(opts)
+	if err != nil {
+		return nil, err
+	}
+	palette := opts.BrandPaletteDictionary
+	if palette == nil {
+		if opts.BrandPalette == "" {
+			return nil, nil
+		}
+		palette, err = colorname.Load(opts.BrandPalette)
+		if err != nil {
+			return nil, fmt.Errorf("action=newBrandChecker err=%v", err)
+		}
+	}
+	return &brandChecker{
+		palette:   palette,
+		maxDeltaE: maxDeltaE,
+		minScore:  minScore,
+	}, nil
+}
+
+// brandThresholds returns opts.BrandMaxDeltaE and opts.BrandMinScore, DefaultBrandMaxDeltaE and
+// DefaultBrandMinScore when nil. 0 is a valid value for both.
+func brandThresholds(opts Options) (float64, float64, error) {
+	maxDeltaE, minScore := float64(DefaultBrandMaxDeltaE), DefaultBrandMinScore
+	if opts.BrandMaxDeltaE != nil {
+		maxDeltaE = *opts.BrandMaxDeltaE
+	}
+	if opts.BrandMinScore != nil {
+		minScore = *opts.BrandMinScore
+	}
+	if maxDeltaE < 0 {
+		return 0, 0, fmt.Errorf("action=brandThresholds brand_max_delta_e=%v err=brand max delta e should not be negative", maxDeltaE)
+	}
+	if minScore < 0 || minScore > 1 {
+		return 0, 0, fmt.Errorf("action=brandThresholds brand_min_score=%v err=brand min score should be within [0, 1]", minScore)
+	}
+	return maxDeltaE, minScore, nil
+}
+
+// check matches every color of segment against the brand palette and scores the segment.
+func (b *brandChecker) check(segment *Segment) {
+	if b == nil {
+		return
+	}
+	compliance := &BrandCompliance{}
+	for i := range segment.Colors {
+		clr := &segment.Colors[i]
+		match := b.palette.Nearest(clr.Color)
+		clr.Brand = &BrandMatch{
+			Name:      match.Name,
+			Hex:       colorspace.Hex(match.Color),
+			DeltaE:    match.Distance,
+			Compliant: match.Distance <= b.maxDeltaE,
+		}
+		if clr.Brand.Compliant {
+			compliance.Score += clr.Weight
+		}
+	}
+	compliance.Violation = compliance.Score < b.minScore
+	segment.Brand = compliance
+	if compliance.Violation {
+		log.Printf("brand violation segment=%v score=%.3f min_score=%v", segment.Number, compliance.Score, b.minScore)
+	}
+}
@@ -0,0 +1,162 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorname"
+)
+
+// writeBrandPalette writes a brand palette csv and returns its path.
+func writeBrandPalette(t *testing.T, rows string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "brand.csv")
+	if err := os.WriteFile(path, []byte(rows), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// threshold returns a pointer to v for the brand threshold options.
+func threshold(v float64) *float64 {
+	return &v
+}
+
+func brandSegment(colors ...PaletteColor) *Segment {
+	return &Segment{
+		Video:    &Video{Serial: "a", URL: "/videos/a.mp4"},
+		ID:       "a-1",
+		Number:   1,
+		End:      10 * time.Second,
+		Duration: 10,
+		Colors:   colors,
+	}
+}
+
+func TestBrandCheckerThresholds(t *testing.T) {
+	path := writeBrandPalette(t, "name,hex\nbrand red,#ff0000\n")
+	exact := PaletteColor{Color: color.RGBA{R: 255, A: 255}, Weight: 0.5}
+	near := PaletteColor{Color: color.RGBA{R: 250, G: 5, A: 255}, Weight: 0.3}
+	far := PaletteColor{Color: color.RGBA{B: 255, A: 255}, Weight: 0.2}
+
+	tests := []struct {
+		name          string
+		maxDeltaE     *float64
+		minScore      *float64
+		wantCompliant []bool
+		wantViolation bool
+		wantErr       bool
+	}{
+		{name: "defaults", wantCompliant: []bool{true, true, false}, wantViolation: false},
+		{name: "exact matches only", maxDeltaE: threshold(0), wantCompliant: []bool{true, false, false}, wantViolation: true},
+		{name: "never a violation", maxDeltaE: threshold(0), minScore: threshold(0), wantCompliant: []bool{true, false, false}, wantViolation: false},
+		{name: "min score 1", maxDeltaE: threshold(1000), minScore: threshold(1), wantCompliant: []bool{true, true, true}, wantViolation: false},
+		{name: "min score above 1", minScore: threshold(1.5), wantErr: true},
+		{name: "negative min score", minScore: threshold(-0.1), wantErr: true},
+		{name: "negative max delta e", maxDeltaE: threshold(-1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := newBrandChecker(Options{BrandPalette: path, BrandMaxDeltaE: tt.maxDeltaE, BrandMinScore: tt.minScore})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newBrandChecker() err=%v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			segment := brandSegment(exact, near, far)
+			checker.check(segment)
+			for i, clr := range segment.Colors {
+				if clr.Brand == nil || clr.Brand.Compliant != tt.wantCompliant[i] {
+					t.Errorf("color %v brand=%+v, want compliant %v", i, clr.Brand, tt.wantCompliant[i])
+				}
+			}
+			if segment.Brand == nil || segment.Brand.Violation != tt.wantViolation {
+				t.Errorf("segment brand=%+v, want violation %v", segment.Brand, tt.wantViolation)
+			}
+		})
+	}
+}
+
+func TestNewBrandCheckerWithoutPalette(t *testing.T) {
+	checker, err := newBrandChecker(Options{BrandMaxDeltaE: threshold(5)})
+	if err != nil || checker != nil {
+		t.Fatalf("newBrandChecker()=%v, %v, want nil without brand palette", checker, err)
+	}
+	segment := brandSegment(PaletteColor{Color: color.RGBA{R: 255, A: 255}, Weight: 1})
+	checker.check(segment)
+	if segment.Brand != nil || segment.Colors[0].Brand != nil {
+		t.Errorf("check without brand palette set brand fields")
+	}
+}
+
+func TestNewBrandCheckerDictionary(t *testing.T) {
+	palette, err := colorname.Read(strings.NewReader("brand red,#ff0000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the dictionary wins over a path, which is never opened
+	checker, err := newBrandChecker(Options{BrandPalette: "/missing/brand.csv", BrandPaletteDictionary: palette})
+	if err != nil {
+		t.Fatal(err)
+	}
+	segment := brandSegment(PaletteColor{Color: color.RGBA{R: 255, A: 255}, Weight: 1})
+	checker.check(segment)
+	if segment.Brand == nil || segment.Colors[0].Brand == nil || segment.Colors[0].Brand.Name != "brand red" {
+		t.Errorf("check with a brand palette dictionary=%+v, want brand red", segment.Colors[0].Brand)
+	}
+}
+
+func TestCSVBrandColumns(t *testing.T) {
+	path := writeBrandPalette(t, "brand red,#ff0000\n")
+	checker, err := newBrandChecker(Options{BrandPalette: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"brand_color", "brand_hex", "brand_delta_e", "brand_compliant", "brand_score", "brand_violation"}
+	tests := []struct {
+		name    string
+		checker *brandChecker
+		want    []string
+	}{
+		{name: "without brand palette", want: []string{"", "", "", "", "", ""}},
+		{name: "with brand palette", checker: checker, want: []string{"brand red", "#ff0000", "0", "true", "1", "false"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segment := brandSegment(PaletteColor{Color: color.RGBA{R: 255, A: 255}, Weight: 1})
+			tt.checker.check(segment)
+			var buf bytes.Buffer
+			sink := NewCSVSink(&buf)
+			if err := sink.Write(segment); err != nil {
+				t.Fatal(err)
+			}
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 2 {
+				t.Fatalf("got %v csv records, want a header and a row", len(records))
+			}
+			index := map[string]int{}
+			for i, name := range records[0] {
+				index[name] = i
+			}
+			for i, column := range columns {
+				j, ok := index[column]
+				if !ok {
+					t.Fatalf("missing column %v", column)
+				}
+				if got := records[1][j]; got != tt.want[i] {
+					t.Errorf("%v=%q, want %q", column, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
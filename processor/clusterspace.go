@@ -0,0 +1,168 @@
+package processor
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/kennykarnama/color-thief/helper"
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+const (
+	ColorSpaceRGB   = "rgb"
+	ColorSpaceLab   = "lab"
+	ColorSpaceOKLab = "oklab"
+)
+
+var colorSpaces = []string{ColorSpaceRGB, ColorSpaceLab, ColorSpaceOKLab}
+
+// spaceOffset centers the signed a/b axes so every channel fits the 0-255 range the extractors expect.
+const spaceOffset = 128
+
+// okLabScale maps OKLab L in [0, 1] onto 0-255. a and b get the same scale so distances stay isotropic.
+const okLabScale = 255
+
+// newPaletteExtractor resolves the extractor selected in opts and, for perceptual color spaces,
+// wraps it so clustering happens in that space.
+func newPaletteExtractor(opts Options) (PaletteExtractor, error) {
+	extractor, err := resolveExtractor(opts.Extractor, opts.FunctionType)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(opts.ColorSpace) {
+	case "", ColorSpaceRGB:
+		return extractor, nil
+	case ColorSpaceLab, ColorSpaceOKLab:
+		return &spaceExtractor{extractor: extractor, space: strings.ToLower(opts.ColorSpace)}, nil
+	default:
+		return nil, fmt.Errorf("action=newPaletteExtractor color_space=%v err=unknown color space, available: %v", opts.ColorSpace, strings.Join(colorSpaces, ", "))
+	}
+}
+
+// paletteWeigher is implemented by extractors that measure pixel shares in their own space.
+type paletteWeigher interface {
+	Weights(pixels [][3]int, palette [][3]int) []float64
+}
+
+// extractPalette runs extractor and returns the palette with the pixel share of each color.
+// wu and wsm pad their palette to paletteSize when the pixels hold fewer colors, with zero vectors
+// and math.MinInt64 sentinels: entries out of the channel range and colors no pixel is nearest to are dropped.
+func extractPalette(extractor PaletteExtractor, pixels [][3]int, paletteSize int) ([][3]int, []float64, error) {
+	extracted, err := extractor.Extract(pixels, paletteSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	palette := make([][3]int, 0, len(extracted))
+	for _, p := range extracted {
+		if inChannelRange(p) {
+			palette = append(palette, p)
+		}
+	}
+	var weights []float64
+	if weigher, ok := extractor.(paletteWeigher); ok {
+		weights = weigher.Weights(pixels, palette)
+	} else {
+		weights = paletteWeights(pixels, palette)
+	}
+	kept := 0
+	for i := range palette {
+		if weights[i] > 0 {
+			palette[kept], weights[kept] = palette[i], weights[i]
+			kept++
+		}
+	}
+	return palette[:kept], weights[:kept], nil
+}
+
+// spaceExtractor clusters sRGB pixels in CIELAB or OKLab and converts the palette back to sRGB.
+type spaceExtractor struct {
+	extractor PaletteExtractor
+	space     string
+}
+
+func (s *spaceExtractor) Extract(pixels [][3]int, paletteSize int) ([][3]int, error) {
+	palette, err := s.extractor.Extract(s.convert(pixels), paletteSize)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][3]int, 0, len(palette))
+	for _, p := range palette {
+		// sentinels do not convert to a color, see extractPalette
+		if !inChannelRange(p) {
+			continue
+		}
+		out = append(out, s.toRGB(p))
+	}
+	return out, nil
+}
+
+func inChannelRange(p [3]int) bool {
+	for _, v := range p {
+		if v < 0 || v > 255 {
+			return false
+		}
+	}
+	return true
+}
+
+// Weights assigns every pixel to its nearest palette color in the clustering space.
+func (s *spaceExtractor) Weights(pixels [][3]int, palette [][3]int) []float64 {
+	return paletteWeights(s.convert(pixels), s.convert(palette))
+}
+
+// convert maps sRGB pixels into the clustering space. Videos repeat colors a lot, so conversions are memoized.
+func (s *spaceExtractor) convert(pixels [][3]int) [][3]int {
+	converted := make([][3]int, len(pixels))
+	seen := make(map[[3]int][3]int)
+	for i, px := range pixels {
+		v, ok := seen[px]
+		if !ok {
+			v = s.fromRGB(px)
+			seen[px] = v
+		}
+		converted[i] = v
+	}
+	return converted
+}
+
+func (s *spaceExtractor) fromRGB(px [3]int) [3]int {
+	c := helper.Color(px)
+	if s.space == ColorSpaceOKLab {
+		o := colorspace.ToOKLab(c)
+		return [3]int{
+			toChannel(o.L * okLabScale),
+			toChannel(o.A*okLabScale + spaceOffset),
+			toChannel(o.B*okLabScale + spaceOffset),
+		}
+	}
+	l := colorspace.ToLab(c)
+	return [3]int{
+		toChannel(l.L),
+		toChannel(l.A + spaceOffset),
+		toChannel(l.B + spaceOffset),
+	}
+}
+
+func (s *spaceExtractor) toRGB(p [3]int) [3]int {
+	var c color.Color
+	if s.space == ColorSpaceOKLab {
+		c = colorspace.OKLab{
+			L: float64(p[0]) / okLabScale,
+			A: float64(p[1]-spaceOffset) / okLabScale,
+			B: float64(p[2]-spaceOffset) / okLabScale,
+		}.RGBA()
+	} else {
+		c = colorspace.Lab{
+			L: float64(p[0]),
+			A: float64(p[1] - spaceOffset),
+			B: float64(p[2] - spaceOffset),
+		}.RGBA()
+	}
+	return toPixel(c)
+}
+
+func toChannel(v float64) int {
+	return int(math.Max(0, math.Min(255, math.Round(v))))
+}
@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/kennykarnama/color-thief/helper"
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+func TestExtractPaletteDropsPadding(t *testing.T) {
+	red, blue := [3]int{255, 0, 0}, [3]int{0, 0, 255}
+	pixels := repeatPixels(map[[3]int]int{red: 100, blue: 300})
+
+	for _, space := range colorSpaces {
+		for _, name := range ExtractorNames() {
+			t.Run(space+"/"+name, func(t *testing.T) {
+				extractor, err := newPaletteExtractor(Options{Extractor: name, ColorSpace: space})
+				if err != nil {
+					t.Fatal(err)
+				}
+				palette, weights, err := extractPalette(extractor, pixels, 5)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(palette) != 2 {
+					t.Fatalf("palette=%v weights=%v, want 2 colors", palette, weights)
+				}
+				for _, want := range []struct {
+					clr    [3]int
+					weight float64
+				}{{blue, 0.75}, {red, 0.25}} {
+					i := nearestCentroid(want.clr, palette)
+					// lab and oklab round trips may be a few units off
+					if d := squaredDistance(want.clr, palette[i]); d > 3*6*6 {
+						t.Errorf("nearest to %v is %v", want.clr, palette[i])
+					}
+					if weights[i] != want.weight {
+						t.Errorf("weight of %v=%v, want %v", palette[i], weights[i], want.weight)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSpaceExtractorRoundTrip(t *testing.T) {
+	colors := [][3]int{{0, 0, 0}, {255, 255, 255}, {255, 0, 0}, {0, 255, 0}, {0, 0, 255}, {128, 64, 200}, {12, 200, 180}}
+	for _, space := range []string{ColorSpaceLab, ColorSpaceOKLab} {
+		s := &spaceExtractor{space: space}
+		for _, c := range colors {
+			got := s.toRGB(s.fromRGB(c))
+			// the clustering space is quantized to integers, so compare perceptually:
+			// near the gamut edge one Lab unit can move an sRGB channel by 10
+			if d := colorspace.DeltaE2000(colorspace.ToLab(helper.Color(c)), colorspace.ToLab(helper.Color(got))); d > 1 {
+				t.Errorf("%v: %v round trips to %v, delta E %.2f", space, c, got, d)
+			}
+		}
+	}
+}
+
+func TestInChannelRange(t *testing.T) {
+	tests := []struct {
+		p    [3]int
+		want bool
+	}{
+		{[3]int{0, 0, 0}, true},
+		{[3]int{255, 255, 255}, true},
+		{[3]int{-1, 0, 0}, false},
+		{[3]int{0, 256, 0}, false},
+		{[3]int{-9223372036854775808, -9223372036854775808, -9223372036854775808}, false},
+	}
+	for _, tt := range tests {
+		if got := inChannelRange(tt.p); got != tt.want {
+			t.Errorf("inChannelRange(%v)=%v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+	"gocv.io/x/gocv"
+)
+
+const (
+	LayoutBelow  = "below"
+	LayoutBeside = "beside"
+
+	ImageFormatPNG  = "png"
+	ImageFormatJPEG = "jpeg"
+	ImageFormatWebP = "webp"
+
+	DefaultSwatchSize   = 160
+	DefaultImageQuality = 90
+)
+
+// webpFileExt is missing from gocv's FileExt constants, opencv picks the encoder from the extension.
+const webpFileExt gocv.FileExt = ".webp"
+
+// CompositeOptions controls the per segment frame+palette image of the visualization.
+type CompositeOptions struct {
+	// Layout puts the palette below (default) or beside the frame.
+	Layout string
+	// SwatchSize is the thickness in pixels of the palette band, DefaultSwatchSize when 0.
+	SwatchSize int
+	// Labels prints each swatch's hex code and weight on it.
+	Labels bool
+	// Format is png (default), jpeg or webp.
+	Format string
+	// Quality is the jpeg/webp quality from 1 to 100, DefaultImageQuality when 0.
+	Quality int
+}
+
+func (c CompositeOptions) withDefaults() CompositeOptions {
+	c.Layout = strings.ToLower(c.Layout)
+	if c.Layout == "" {
+		c.Layout = LayoutBelow
+	}
+	c.Format = strings.ToLower(c.Format)
+	switch c.Format {
+	case "":
+		c.Format = ImageFormatPNG
+	case "jpg":
+		c.Format = ImageFormatJPEG
+	}
+	if c.SwatchSize <= 0 {
+		c.SwatchSize = DefaultSwatchSize
+	}
+	if c.Quality <= 0 {
+		c.Quality = DefaultImageQuality
+	}
+	return c
+}
+
+func (c CompositeOptions) validate() error {
+	c = c.withDefaults()
+	if c.Layout != LayoutBelow && c.Layout != LayoutBeside {
+		return fmt.Errorf("action=validateComposite layout=%v err=layout should be either %v or %v", c.Layout, LayoutBelow, LayoutBeside)
+	}
+	switch c.Format {
+	case ImageFormatPNG, ImageFormatJPEG, ImageFormatWebP:
+	default:
+		return fmt.Errorf("action=validateComposite format=%v err=image format should be %v, %v or %v", c.Format, ImageFormatPNG, ImageFormatJPEG, ImageFormatWebP)
+	}
+	if c.Quality > 100 {
+		return fmt.Errorf("action=validateComposite quality=%v err=quality should be within 1 and 100", c.Quality)
+	}
+	return nil
+}
+
+// extension returns the file extension of the configured image format.
+func (c CompositeOptions) extension() string {
+	switch c.withDefaults().Format {
+	case ImageFormatJPEG:
+		return ".jpg"
+	case ImageFormatWebP:
+		return ".webp"
+	default:
+		return ".png"
+	}
+}
+
+// Composite draws frame with its palette in one image, without going through files.
+// Swatches share the band equally, in palette order.
+func Composite(frame image.Image, colors []PaletteColor, opts CompositeOptions) *image.RGBA {
+	opts = opts.withDefaults()
+	fb := frame.Bounds()
+	fw, fh := fb.Dx(), fb.Dy()
+
+	var canvas *image.RGBA
+	var band image.Rectangle
+	if opts.Layout == LayoutBeside {
+		canvas = image.NewRGBA(image.Rect(0, 0, fw+opts.SwatchSize, fh))
+		band = image.Rect(fw, 0, fw+opts.SwatchSize, fh)
+	} else {
+		canvas = image.NewRGBA(image.Rect(0, 0, fw, fh+opts.SwatchSize))
+		band = image.Rect(0, fh, fw, fh+opts.SwatchSize)
+	}
+	draw.Draw(canvas, image.Rect(0, 0, fw, fh), frame, fb.Min, draw.Src)
+
+	n := len(colors)
+	for i, clr := range colors {
+		var swatch image.Rectangle
+		if opts.Layout == LayoutBeside {
+			swatch = image.Rect(band.Min.X, band.Min.Y+i*band.Dy()/n, band.Max.X, band.Min.Y+(i+1)*band.Dy()/n)
+		} else {
+			swatch = image.Rect(band.Min.X+i*band.Dx()/n, band.Min.Y, band.Min.X+(i+1)*band.Dx()/n, band.Max.Y)
+		}
+		draw.Draw(canvas, swatch, &image.Uniform{clr.Color}, image.Point{}, draw.Src)
+		if opts.Labels {
+			drawLabel(canvas, swatch, clr)
+		}
+	}
+	return canvas
+}
+
+// EncodeImage writes img as png, jpeg or webp. webp goes through opencv since the standard library has no encoder.
+func EncodeImage(w io.Writer, img image.Image, opts CompositeOptions) error {
+	opts = opts.withDefaults()
+	switch opts.Format {
+	case ImageFormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: opts.Quality})
+	case ImageFormatWebP:
+		mat, err := gocv.ImageToMatRGB(img)
+		if err != nil {
+			return fmt.Errorf("action=encodeImage format=%v err=%v", opts.Format, err)
+		}
+		defer mat.Close()
+		buf, err := gocv.IMEncodeWithParams(webpFileExt, mat, []int{gocv.IMWriteWebpQuality, opts.Quality})
+		if err != nil {
+			return fmt.Errorf("action=encodeImage format=%v err=%v", opts.Format, err)
+		}
+		defer buf.Close()
+		if buf.Len() == 0 {
+			return fmt.Errorf("action=encodeImage format=%v err=opencv was built without webp support", opts.Format)
+		}
+		_, err = w.Write(buf.GetBytes())
+		return err
+	default:
+		return png.Encode(w, img)
+	}
+}
+
+// writeComposite writes the frame+palette image of one segment to out.
+func writeComposite(out string, frame image.Image, colors []PaletteColor, opts CompositeOptions) error {
+	if frame == nil {
+		return fmt.Errorf("action=writeComposite out=%v err=no frame kept for segment", out)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("action=writeComposite out=%v err=%v", out, err)
+	}
+	err = EncodeImage(f, Composite(frame, colors, opts), opts)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out)
+		return fmt.Errorf("action=writeComposite out=%v err=%v", out, err)
+	}
+	return nil
+}
+
+// labelGlyphs is a 5x7 bitmap font covering hex codes and weights, so labels need no font files.
+// Each row is 5 bits, the most significant one on the left.
+var labelGlyphs = map[rune][7]uint8{
+	'0': {0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e},
+	'1': {0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e},
+	'2': {0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f},
+	'3': {0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e},
+	'4': {0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02},
+	'5': {0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e},
+	'6': {0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e},
+	'7': {0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e},
+	'9': {0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c},
+	'a': {0x00, 0x00, 0x0e, 0x01, 0x0f, 0x11, 0x0f},
+	'b': {0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1e},
+	'c': {0x00, 0x00, 0x0e, 0x10, 0x10, 0x11, 0x0e},
+	'd': {0x01, 0x01, 0x0d, 0x13, 0x11, 0x11, 0x0f},
+	'e': {0x00, 0x00, 0x0e, 0x11, 0x1f, 0x10, 0x0e},
+	'f': {0x06, 0x09, 0x08, 0x1c, 0x08, 0x08, 0x08},
+	'#': {0x0a, 0x0a, 0x1f, 0x0a, 0x1f, 0x0a, 0x0a},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x0c},
+	'%': {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+}
+
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphSpacing = 1
+)
+
+// drawLabel prints the hex code and weight of clr in the top left of swatch, as large as fits,
+// in black or white depending on the swatch lightness. Labels that do not fit are skipped.
+func drawLabel(canvas *image.RGBA, swatch image.Rectangle, clr PaletteColor) {
+	lines := []string{colorspace.Hex(clr.Color), fmt.Sprintf("%.1f%%", clr.Weight*100)}
+	ink := color.RGBA{A: 0xff}
+	if colorspace.ToLab(clr.Color).L < 55 {
+		ink = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	}
+	columns := 0
+	for _, line := range lines {
+		if len(line) > columns {
+			columns = len(line)
+		}
+	}
+	textWidth := columns*(glyphWidth+glyphSpacing) + glyphSpacing
+	textHeight := len(lines)*(glyphHeight+glyphSpacing) + glyphSpacing
+	scale := swatch.Dx() / textWidth
+	if s := swatch.Dy() / textHeight; s < scale {
+		scale = s
+	}
+	if scale > 4 {
+		scale = 4
+	}
+	if scale < 1 {
+		return
+	}
+	for row, line := range lines {
+		y := swatch.Min.Y + (glyphSpacing+row*(glyphHeight+glyphSpacing))*scale
+		for col, r := range line {
+			glyph, ok := labelGlyphs[r]
+			if !ok {
+				continue
+			}
+			x := swatch.Min.X + (glyphSpacing+col*(glyphWidth+glyphSpacing))*scale
+			for gy, bits := range glyph {
+				for gx := 0; gx < glyphWidth; gx++ {
+					if bits&(1<<(glyphWidth-1-gx)) == 0 {
+						continue
+					}
+					dot := image.Rect(x+gx*scale, y+gy*scale, x+(gx+1)*scale, y+(gy+1)*scale)
+					draw.Draw(canvas, dot, &image.Uniform{ink}, image.Point{}, draw.Src)
+				}
+			}
+		}
+	}
+}
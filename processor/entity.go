@@ -1,38 +1,228 @@
 package processor
 
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/kennykarnama/color-thief/helper"
+	"github.com/kennykarnama/video-color-palette-generator/colorname"
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
 
 type Parameter  struct {
 	InputFile      string         `arg:"--input-file,-i" help:"input file path for video"`
-	InputSerial    string `arg:"--input-serial" help:"input serial for video"`
-	PeriodDuration float64        `arg:"--period-duration,-d" help:"period duration in seconds"`
-	PaletteSize    int            `arg:"--palette-size,-k" help:"palette size"`
-	FunctionType   int            `arg:"--function-type" help:"function type. 0 --> quant_wu, 1 --> WSM_WU"`
-	CsvResult      string         `arg:"--csv-result,-o" help:"csv result path"`
+	Options
+	CsvResult      string         `arg:"--csv-result,-o" help:"result path"`
+	Format         string         `arg:"--format,-f" default:"csv" help:"result format: csv, json, jsonl, parquet or parquet-nested"`
+	SummaryResult  string         `arg:"--summary-result" help:"path for the whole-video summary palette, written in --format with sample_number 0"`
 	VisualizeCmd   *VisualizeArgs `arg:"subcommand:visualize"`
 }
 
+// Options controls how palettes are extracted from a video.
+type Options struct {
+	InputSerial      string   `arg:"--input-serial" help:"input serial for video"`
+	PeriodDuration   float64  `arg:"--period-duration,-d" help:"period duration in seconds"`
+	PaletteSize      int      `arg:"--palette-size,-k" help:"palette size"`
+	FunctionType     int      `arg:"--function-type" help:"function type. 0 --> quant_wu, 1 --> WSM_WU. Ignored when --extractor is set"`
+	Extractor        string   `arg:"--extractor,-e" help:"palette extractor name: wu, wsm, kmeans, mediancut or octree"`
+	ColorSpace       string   `arg:"--color-space" default:"rgb" help:"space the extractor clusters in: rgb, lab (CIELAB) or oklab. Palettes are always reported in sRGB"`
+	ColorNames       string   `arg:"--color-names" default:"css" help:"dictionary palette colors are named from: css (CSS/X11 names) or a csv file of name,hex rows"`
+	BrandPalette     string   `arg:"--brand-palette" help:"csv file of name,hex brand colors. Reports each color's nearest brand color and a compliance score per segment"`
+	BrandMaxDeltaE   *float64 `arg:"--brand-max-delta-e" help:"CIEDE2000 distance up to which a palette color counts as on brand, 0 for exact matches only (default 10)"`
+	BrandMinScore    *float64 `arg:"--brand-min-score" help:"segments whose compliant pixel share is below this score are flagged as violations, 0 never flags (default 0.8)"`
+	Workers          int      `arg:"--workers,-w" default:"1" help:"number of segments decoded and clustered in parallel, each worker opens its own video capture"`
+	Segmentation     string   `arg:"--segmentation" default:"fixed" help:"fixed: one palette per --period-duration window, scene: one palette per detected shot"`
+	SceneThreshold   float64  `arg:"--scene-threshold" default:"0.4" help:"histogram distance (0-1) between consecutive frames that starts a new scene"`
+	MinSceneDuration float64  `arg:"--min-scene-duration" default:"1" help:"minimum scene length in seconds"`
+	FramesPerSegment int      `arg:"--frames-per-segment" default:"1" help:"frames sampled evenly across each segment and pooled into one palette. -1 pools every frame"`
+	ScaleFactor      float64  `arg:"--scale-factor" help:"resize factor in (0, 1] applied to frames before clustering. Defaults to 0.1 when no other size is set"`
+	MaxPixels        int      `arg:"--max-pixels" help:"downscale frames so width*height stays within this pixel budget"`
+	TargetLongEdge   int      `arg:"--target-long-edge" help:"downscale frames so their longest edge is this many pixels"`
+	Interpolation    string   `arg:"--interpolation" default:"cubic" help:"resize interpolation: nearest, linear, cubic, area or lanczos4"`
+	// VisualizeFolder, when set, receives a frame+palette image per segment, drawn according to Composite.
+	VisualizeFolder string           `arg:"-"`
+	Composite       CompositeOptions `arg:"-"`
+	// ColorNamesDictionary, when set, is used instead of loading ColorNames.
+	ColorNamesDictionary *colorname.Dictionary `arg:"-"`
+	// BrandPaletteDictionary, when set, is used instead of loading BrandPalette.
+	BrandPaletteDictionary *colorname.Dictionary `arg:"-"`
+}
 
 type VisualizeArgs struct {
-	OutputFolder string `arg:"--visualize-output-folder" help:"visualization output folder. Contains frame and color palette"`
+	OutputFolder   string `arg:"--visualize-output-folder" help:"visualization output folder. Contains frame and color palette"`
+	Timeline       bool   `arg:"--timeline" help:"also write timeline.png, the whole video as one stripe per segment with its colors stacked by weight"`
+	TimelineSVG    bool   `arg:"--timeline-svg" help:"also write the timeline as timeline.svg"`
+	TimelineWidth  int    `arg:"--timeline-width" default:"1920" help:"timeline width in pixels, stripes are as wide as the time their segment covers"`
+	TimelineHeight int    `arg:"--timeline-height" default:"270" help:"timeline height in pixels"`
+	Layout         string `arg:"--layout" default:"below" help:"palette position in the segment images: below or beside the frame"`
+	SwatchSize     int    `arg:"--swatch-size" default:"160" help:"thickness of the palette band in pixels"`
+	Labels         bool   `arg:"--labels" help:"print each swatch's hex code and weight on it"`
+	ImageFormat    string `arg:"--image-format" default:"png" help:"segment image format: png, jpeg or webp"`
+	Quality        int    `arg:"--quality" default:"90" help:"jpeg and webp quality from 1 to 100"`
+	Report         bool   `arg:"--report" help:"also write report.html, a self-contained page with the timeline, summary palette and every segment's thumbnail and palette"`
+	ThumbnailWidth int    `arg:"--thumbnail-width" default:"320" help:"width in pixels of the report thumbnails"`
 }
 
+// Result is one palette color of a segment, a row of the csv output. The brand columns are empty
+// without a brand palette.
 type Result struct {
-	SourceSerial          string  `csv:"source_serial"`
-	SourceURL             string  `csv:"source_url"`
-	SourceDurationSeconds float64 `csv:"source_duration_seconds"`
-	SourceFPS             float64 `csv:"source_fps"`
-	SampleID              string  `csv:"sample_id"`
-	SampleNumber          int     `csv:"sample_number"`
-	SampleDuration        float64 `csv:"sample_duration"`
-	PaletteID             string  `csv:"palette_id"`
-	PaletteCounts         int     `csv:"palette_counts"`
-	R                     uint32  `csv:"r"`
-	G                     uint32  `csv:"g"`
-	B                     uint32  `csv:"b"`
-	A                     uint32  `csv:"a"`
-	RNorm                 float64 `csv:"r_norm"`
-	GNorm                 float64 `csv:"g_norm"`
-	BNorm                 float64 `csv:"b_norm"`
+	SourceSerial          string   `csv:"source_serial"`
+	SourceURL             string   `csv:"source_url"`
+	SourceDurationSeconds float64  `csv:"source_duration_seconds"`
+	SourceFPS             float64  `csv:"source_fps"`
+	SampleID              string   `csv:"sample_id"`
+	SampleNumber          int      `csv:"sample_number"`
+	SampleDuration        float64  `csv:"sample_duration"`
+	PaletteID             string   `csv:"palette_id"`
+	PaletteCounts         int      `csv:"palette_counts"`
+	R                     uint32   `csv:"r"`
+	G                     uint32   `csv:"g"`
+	B                     uint32   `csv:"b"`
+	A                     uint32   `csv:"a"`
+	RNorm                 float64  `csv:"r_norm"`
+	GNorm                 float64  `csv:"g_norm"`
+	BNorm                 float64  `csv:"b_norm"`
+	Weight                float64  `csv:"weight"`
+	SampleStartMs         int64    `csv:"sample_start_ms"`
+	SampleEndMs           int64    `csv:"sample_end_ms"`
+	FrameMs               int64    `csv:"frame_ms"`
+	R8                    uint8    `csv:"r8"`
+	G8                    uint8    `csv:"g8"`
+	B8                    uint8    `csv:"b8"`
+	Hex                   string   `csv:"hex"`
+	HSVH                  float64  `csv:"hsv_h"`
+	HSVS                  float64  `csv:"hsv_s"`
+	HSVV                  float64  `csv:"hsv_v"`
+	HSLH                  float64  `csv:"hsl_h"`
+	HSLS                  float64  `csv:"hsl_s"`
+	HSLL                  float64  `csv:"hsl_l"`
+	LabL                  float64  `csv:"lab_l"`
+	LabA                  float64  `csv:"lab_a"`
+	LabB                  float64  `csv:"lab_b"`
+	LChC                  float64  `csv:"lch_c"`
+	LChH                  float64  `csv:"lch_h"`
+	ColorName             string   `csv:"color_name"`
+	ColorNameDistance     float64  `csv:"color_name_distance"`
+	BrandColor            string   `csv:"brand_color"`
+	BrandHex              string   `csv:"brand_hex"`
+	BrandDeltaE           *float64 `csv:"brand_delta_e"`
+	BrandCompliant        *bool    `csv:"brand_compliant"`
+	BrandScore            *float64 `csv:"brand_score"`
+	BrandViolation        *bool    `csv:"brand_violation"`
+}
+
+// Video describes the source video of a set of segments.
+type Video struct {
+	Serial          string
+	URL             string
+	DurationSeconds float64
+	FPS             float64
+}
+
+// NoFrameMs is the frame_ms of segments no frame was decoded for, like the summary palette.
+const NoFrameMs = -1
+
+// Segment is one sampled period of a video together with its palette.
+type Segment struct {
+	Video  *Video
+	ID     string
+	Number int
+	Start  time.Duration
+	End    time.Duration
+	// FrameTime is the decoded timestamp of the first frame the palette was extracted from, 0 being a
+	// frame at the video start. It is negative when no frame was decoded, like for the summary palette.
+	FrameTime time.Duration
+	Duration  float64
+	PaletteID string
+	Colors    []PaletteColor
+	// Brand is set when a brand palette is configured.
+	Brand *BrandCompliance
+	// Frame is the first sampled frame, only kept when visualizing.
+	Frame image.Image
+}
+
+// PaletteColor is one palette entry and the share of sampled pixels it covers.
+type PaletteColor struct {
+	Color  color.Color
+	Weight float64
+	// Name is the nearest dictionary color and NameDistance its CIEDE2000 distance.
+	Name         string
+	NameDistance float64
+	// Brand is set when a brand palette is configured.
+	Brand *BrandMatch
+}
+
+func newPaletteColors(palette [][3]int, weights []float64, names *colorname.Dictionary) []PaletteColor {
+	colors := make([]PaletteColor, len(palette))
+	for i, p := range palette {
+		colors[i] = PaletteColor{Color: helper.Color(p), Weight: weights[i]}
+		if names != nil {
+			match := names.Nearest(colors[i].Color)
+			colors[i].Name, colors[i].NameDistance = match.Name, match.Distance
+		}
+	}
+	return colors
+}
+
+// frameMs returns FrameTime in milliseconds, NoFrameMs when no frame was decoded.
+func (s *Segment) frameMs() int64 {
+	if s.FrameTime < 0 {
+		return NoFrameMs
+	}
+	return s.FrameTime.Round(time.Millisecond).Milliseconds()
+}
+
+// Results flattens the segment into one Result per palette color.
+func (s *Segment) Results() []*Result {
+	var results []*Result
+	for _, clr := range s.Colors {
+		result := &Result{
+			SourceURL:             s.Video.URL,
+			SourceSerial:          s.Video.Serial,
+			SourceDurationSeconds: s.Video.DurationSeconds,
+			SourceFPS:             s.Video.FPS,
+			SampleID:              s.ID,
+			SampleNumber:          s.Number,
+			SampleDuration:        s.Duration,
+			PaletteCounts:         len(s.Colors),
+			PaletteID:             s.PaletteID,
+			Weight:                clr.Weight,
+			SampleStartMs:         s.Start.Milliseconds(),
+			SampleEndMs:           s.End.Milliseconds(),
+			FrameMs:               s.frameMs(),
+			ColorName:             clr.Name,
+			ColorNameDistance:     clr.NameDistance,
+		}
+		if clr.Brand != nil {
+			result.BrandColor = clr.Brand.Name
+			result.BrandHex = clr.Brand.Hex
+			result.BrandDeltaE = &clr.Brand.DeltaE
+			result.BrandCompliant = &clr.Brand.Compliant
+		}
+		if s.Brand != nil {
+			result.BrandScore = &s.Brand.Score
+			result.BrandViolation = &s.Brand.Violation
+		}
+		result.R, result.G, result.B, result.A = clr.Color.RGBA()
+		result.Normalize16BitRGB()
+		result.SetColorSpaces(clr.Color)
+		results = append(results, result)
+	}
+	return results
+}
+
+// SetColorSpaces fills the 8-bit, hex, HSV, HSL, CIELAB and LCh columns from c.
+// The LCh lightness equals lab_l and is not repeated.
+func (r *Result) SetColorSpaces(c color.Color) {
+	r.R8, r.G8, r.B8 = colorspace.RGB8(c)
+	r.Hex = colorspace.Hex(c)
+	r.HSVH, r.HSVS, r.HSVV = colorspace.HSV(c)
+	r.HSLH, r.HSLS, r.HSLL = colorspace.HSL(c)
+	lab := colorspace.ToLab(c)
+	r.LabL, r.LabA, r.LabB = lab.L, lab.A, lab.B
+	lch := lab.LCh()
+	r.LChC, r.LChH = lch.C, lch.H
 }
 
 func (r *Result) Normalize16BitRGB() {
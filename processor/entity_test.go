@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSegmentFrameMs(t *testing.T) {
+	tests := []struct {
+		name      string
+		frameTime time.Duration
+		want      int64
+	}{
+		{name: "frame at the video start", frameTime: 0, want: 0},
+		{name: "rounded to milliseconds", frameTime: 1500*time.Millisecond + 600*time.Microsecond, want: 1501},
+		{name: "no frame", frameTime: NoFrameMs * time.Millisecond, want: NoFrameMs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segment := brandSegment(PaletteColor{Color: color.RGBA{R: 255, A: 255}, Weight: 1})
+			segment.FrameTime = tt.frameTime
+			if got := segment.Results()[0].FrameMs; got != tt.want {
+				t.Errorf("Results() frame_ms=%v, want %v", got, tt.want)
+			}
+			if got := segment.Record().FrameMs; got != tt.want {
+				t.Errorf("Record() frameMs=%v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryHasNoFrame(t *testing.T) {
+	segment := brandSegment(PaletteColor{Color: color.RGBA{R: 255, A: 255}, Weight: 1})
+	segment.FrameTime = 0
+	summary, err := Summarize([]*Segment{segment}, Options{Extractor: "kmeans", PaletteSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := summary.Record().FrameMs; got != NoFrameMs {
+		t.Errorf("summary frameMs=%v, want %v", got, NoFrameMs)
+	}
+	if got := newReportSegment(summary, "").Frame; got != "none" {
+		t.Errorf("summary report frame=%v, want none", got)
+	}
+}
+
+func TestSummaryWithoutSegments(t *testing.T) {
+	summary, err := Summarize(nil, Options{Extractor: "kmeans", PaletteSize: 1})
+	if err != nil || summary != nil {
+		t.Fatalf("Summarize(nil)=%v, %v, want nil without error", summary, err)
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: FormatCSV, want: ""},
+		{format: FormatJSON, want: "{\n  \"video\": null,\n  \"segments\": []\n}\n"},
+		{format: FormatJSONL, want: ""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "summary."+tt.format)
+		if err := writeSummary(path, tt.format, nil); err != nil {
+			t.Fatalf("writeSummary(%v) err=%v", tt.format, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("writeSummary(%v) wrote %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
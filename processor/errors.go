@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCanceled matches any CanceledError through errors.Is.
+var ErrCanceled = errors.New("palette extraction canceled")
+
+// CanceledError is returned when extraction stops because its context was canceled or its deadline passed.
+// Segment is the number of the last segment handed to the caller before stopping.
+type CanceledError struct {
+	Segment int
+	Err     error
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("action=run.canceled segment=%v err=%v", e.Segment, e.Err)
+}
+
+// Unwrap exposes the context error, so errors.Is(err, context.DeadlineExceeded) works too.
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CanceledError) Is(target error) bool {
+	return target == ErrCanceled
+}
+
+// checkCanceled returns a CanceledError once ctx is done.
+func checkCanceled(ctx context.Context, segment int) error {
+	if err := ctx.Err(); err != nil {
+		return &CanceledError{Segment: segment, Err: err}
+	}
+	return nil
+}
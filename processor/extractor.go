@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kennykarnama/color-thief/wsm"
+	"github.com/kennykarnama/color-thief/wu"
+)
+
+// PaletteExtractor clusters sampled RGB pixels into a palette of at most paletteSize colors.
+type PaletteExtractor interface {
+	Extract(pixels [][3]int, paletteSize int) ([][3]int, error)
+}
+
+const (
+	ExtractorWu        = "wu"
+	ExtractorWSM       = "wsm"
+	ExtractorKMeans    = "kmeans"
+	ExtractorMedianCut = "mediancut"
+	ExtractorOctree    = "octree"
+)
+
+var (
+	ErrNoPixels = errors.New("no pixels to extract palette from")
+
+	extractorsMu sync.RWMutex
+	extractors   = map[string]PaletteExtractor{}
+)
+
+func init() {
+	RegisterExtractor(ExtractorWu, wuExtractor{})
+	RegisterExtractor(ExtractorWSM, wsmExtractor{})
+	RegisterExtractor(ExtractorKMeans, &kmeansExtractor{MaxIterations: 20, Seed: 1})
+	RegisterExtractor(ExtractorMedianCut, medianCutExtractor{})
+	RegisterExtractor(ExtractorOctree, octreeExtractor{})
+}
+
+// RegisterExtractor makes a palette extractor available by name.
+// Registering the same name twice replaces the previous extractor.
+func RegisterExtractor(name string, extractor PaletteExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors[strings.ToLower(name)] = extractor
+}
+
+// GetExtractor returns the extractor registered under name.
+func GetExtractor(name string) (PaletteExtractor, error) {
+	extractorsMu.RLock()
+	extractor, ok := extractors[strings.ToLower(name)]
+	extractorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("action=getExtractor name=%v err=unknown extractor, available extractors: %v", name, strings.Join(ExtractorNames(), ", "))
+	}
+	return extractor, nil
+}
+
+// ExtractorNames lists the registered extractor names in alphabetical order.
+func ExtractorNames() []string {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	var names []string
+	for name := range extractors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// extractorNameFromFunctionType maps the legacy color-thief function type to an extractor name.
+func extractorNameFromFunctionType(functionType int) (string, error) {
+	switch functionType {
+	case 0:
+		return ExtractorWu, nil
+	case 1:
+		return ExtractorWSM, nil
+	default:
+		return "", fmt.Errorf("action=extractorNameFromFunctionType function_type=%v err=function type should be either 0 or 1, or use an extractor name: %v", functionType, strings.Join(ExtractorNames(), ", "))
+	}
+}
+
+// resolveExtractor picks the extractor by name, falling back to the legacy function type when no name is given.
+func resolveExtractor(name string, functionType int) (PaletteExtractor, error) {
+	if name == "" {
+		var err error
+		name, err = extractorNameFromFunctionType(functionType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return GetExtractor(name)
+}
+
+type wuExtractor struct{}
+
+func (wuExtractor) Extract(pixels [][3]int, paletteSize int) ([][3]int, error) {
+	if len(pixels) == 0 {
+		return nil, ErrNoPixels
+	}
+	return wu.QuantWu(pixels, paletteSize), nil
+}
+
+type wsmExtractor struct{}
+
+func (wsmExtractor) Extract(pixels [][3]int, paletteSize int) ([][3]int, error) {
+	if len(pixels) == 0 {
+		return nil, ErrNoPixels
+	}
+	return wsm.WSM(pixels, paletteSize), nil
+}
+
+// paletteWeights returns, for each palette color, the fraction of pixels whose nearest palette color it is.
+func paletteWeights(pixels [][3]int, palette [][3]int) []float64 {
+	weights := make([]float64, len(palette))
+	if len(pixels) == 0 || len(palette) == 0 {
+		return weights
+	}
+	for _, px := range pixels {
+		weights[nearestCentroid(px, palette)]++
+	}
+	for i := range weights {
+		weights[i] /= float64(len(pixels))
+	}
+	return weights
+}
+
+func squaredDistance(a, b [3]int) int {
+	dr := a[0] - b[0]
+	dg := a[1] - b[1]
+	db := a[2] - b[2]
+	return dr*dr + dg*dg + db*db
+}
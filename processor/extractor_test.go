@@ -0,0 +1,184 @@
+package processor
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// repeatPixels returns counts[c] copies of each color c, colors in a fixed order.
+func repeatPixels(counts map[[3]int]int) [][3]int {
+	colors := make([][3]int, 0, len(counts))
+	for clr := range counts {
+		colors = append(colors, clr)
+	}
+	sort.Slice(colors, func(i, j int) bool { return lessColor(colors[i], colors[j]) })
+	var pixels [][3]int
+	for _, clr := range colors {
+		for i := 0; i < counts[clr]; i++ {
+			pixels = append(pixels, clr)
+		}
+	}
+	return pixels
+}
+
+func lessColor(a, b [3]int) bool {
+	for ch := 0; ch < 3; ch++ {
+		if a[ch] != b[ch] {
+			return a[ch] < b[ch]
+		}
+	}
+	return false
+}
+
+// sortedPalette sorts palette colors and their weights together, for order independent comparisons.
+func sortedPalette(palette [][3]int, weights []float64) ([][3]int, []float64) {
+	idx := make([]int, len(palette))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool { return lessColor(palette[idx[a]], palette[idx[b]]) })
+	sp := make([][3]int, len(idx))
+	sw := make([]float64, len(idx))
+	for i, j := range idx {
+		sp[i], sw[i] = palette[j], weights[j]
+	}
+	return sp, sw
+}
+
+func TestExtractorsFewerColorsThanPaletteSize(t *testing.T) {
+	red, blue := [3]int{255, 0, 0}, [3]int{0, 0, 255}
+	pixels := repeatPixels(map[[3]int]int{red: 100, blue: 300})
+	wantPalette := [][3]int{blue, red}
+	wantWeights := []float64{0.75, 0.25}
+
+	for _, name := range []string{ExtractorKMeans, ExtractorMedianCut, ExtractorOctree} {
+		t.Run(name, func(t *testing.T) {
+			extractor, err := GetExtractor(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			palette, weights, err := extractPalette(extractor, pixels, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			palette, weights = sortedPalette(palette, weights)
+			if !reflect.DeepEqual(palette, wantPalette) || !reflect.DeepEqual(weights, wantWeights) {
+				t.Errorf("palette=%v weights=%v, want %v %v", palette, weights, wantPalette, wantWeights)
+			}
+		})
+	}
+}
+
+func TestExtractorsSeparateClusters(t *testing.T) {
+	// four tight clusters, each color within 4 of its cluster center
+	centers := [][3]int{{20, 20, 20}, {230, 30, 30}, {30, 200, 40}, {240, 240, 220}}
+	counts := map[[3]int]int{}
+	for _, c := range centers {
+		for _, d := range [][3]int{{0, 0, 0}, {4, 0, 0}, {0, 4, 0}, {0, 0, 4}} {
+			counts[[3]int{c[0] + d[0], c[1] + d[1], c[2] + d[2]}] = 25
+		}
+	}
+	pixels := repeatPixels(counts)
+
+	for _, name := range []string{ExtractorKMeans, ExtractorMedianCut, ExtractorOctree} {
+		t.Run(name, func(t *testing.T) {
+			extractor, err := GetExtractor(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			palette, weights, err := extractPalette(extractor, pixels, len(centers))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(palette) != len(centers) {
+				t.Fatalf("got %v colors %v, want %v", len(palette), palette, len(centers))
+			}
+			for _, c := range centers {
+				i := nearestCentroid(c, palette)
+				if d := squaredDistance(c, palette[i]); d > 3*8*8 {
+					t.Errorf("center %v nearest palette color %v is too far, squared distance %v", c, palette[i], d)
+				}
+				if weights[i] != 0.25 {
+					t.Errorf("center %v weight=%v, want 0.25", c, weights[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractorsNoPixels(t *testing.T) {
+	for _, name := range ExtractorNames() {
+		extractor, err := GetExtractor(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := extractor.Extract(nil, 5); err != ErrNoPixels {
+			t.Errorf("%v: err=%v, want ErrNoPixels", name, err)
+		}
+	}
+}
+
+func TestMedianCutSplitsBetweenValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		pixels      [][3]int
+		paletteSize int
+		want        [][3]int
+	}{
+		{
+			name:        "two colors, no duplicates",
+			pixels:      repeatPixels(map[[3]int]int{{255, 0, 0}: 100, {0, 0, 255}: 300}),
+			paletteSize: 5,
+			want:        [][3]int{{0, 0, 255}, {255, 0, 0}},
+		},
+		{
+			name:        "single color",
+			pixels:      repeatPixels(map[[3]int]int{{10, 20, 30}: 7}),
+			paletteSize: 3,
+			want:        [][3]int{{10, 20, 30}},
+		},
+		{
+			name:        "split nearest the median",
+			pixels:      repeatPixels(map[[3]int]int{{0, 0, 0}: 1, {100, 0, 0}: 5, {200, 0, 0}: 1}),
+			paletteSize: 2,
+			want:        [][3]int{{0, 0, 0}, {117, 0, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := medianCutExtractor{}.Extract(tt.pixels, tt.paletteSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ = sortedPalette(got, make([]float64, len(got)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract()=%v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		want   int
+	}{
+		{name: "median on a boundary", values: []int{1, 1, 2, 2}, want: 2},
+		{name: "boundary before the median", values: []int{1, 2, 2, 2, 2}, want: 1},
+		{name: "boundary after the median", values: []int{1, 1, 1, 1, 2}, want: 4},
+		{name: "nearest of two boundaries", values: []int{1, 2, 2, 2, 2, 2, 3, 3}, want: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pixels := make([][3]int, len(tt.values))
+			for i, v := range tt.values {
+				pixels[i] = [3]int{v, 0, 0}
+			}
+			if got := splitIndex(pixels, 0); got != tt.want {
+				t.Errorf("splitIndex(%v)=%v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"math"
+	"math/rand"
+)
+
+// kmeansExtractor runs Lloyd's k-means seeded with k-means++.
+// The seed is fixed so the same frame always yields the same palette.
+type kmeansExtractor struct {
+	MaxIterations int
+	Seed          int64
+}
+
+func (km *kmeansExtractor) Extract(pixels [][3]int, paletteSize int) ([][3]int, error) {
+	if len(pixels) == 0 {
+		return nil, ErrNoPixels
+	}
+	k := paletteSize
+	if k > len(pixels) {
+		k = len(pixels)
+	}
+
+	centroids := km.seed(pixels, k)
+	assignments := make([]int, len(pixels))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+
+	sums := make([][3]float64, k)
+	counts := make([]int, k)
+	for iter := 0; iter < km.MaxIterations; iter++ {
+		changed := false
+		for i, px := range pixels {
+			nearest := nearestCentroid(px, centroids)
+			if nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		for c := range sums {
+			sums[c] = [3]float64{}
+			counts[c] = 0
+		}
+		for i, px := range pixels {
+			c := assignments[i]
+			sums[c][0] += float64(px[0])
+			sums[c][1] += float64(px[1])
+			sums[c][2] += float64(px[2])
+			counts[c]++
+		}
+		for c := range centroids {
+			// an empty cluster keeps its previous centroid
+			if counts[c] == 0 {
+				continue
+			}
+			n := float64(counts[c])
+			centroids[c] = [3]int{
+				int(math.Round(sums[c][0] / n)),
+				int(math.Round(sums[c][1] / n)),
+				int(math.Round(sums[c][2] / n)),
+			}
+		}
+	}
+
+	used := make([]bool, k)
+	for _, c := range assignments {
+		if c >= 0 {
+			used[c] = true
+		}
+	}
+	var palette [][3]int
+	for c, centroid := range centroids {
+		if used[c] {
+			palette = append(palette, centroid)
+		}
+	}
+	return palette, nil
+}
+
+// seed picks initial centroids using k-means++.
+func (km *kmeansExtractor) seed(pixels [][3]int, k int) [][3]int {
+	rnd := rand.New(rand.NewSource(km.Seed))
+	centroids := make([][3]int, 0, k)
+	centroids = append(centroids, pixels[rnd.Intn(len(pixels))])
+
+	distances := make([]float64, len(pixels))
+	for len(centroids) < k {
+		total := float64(0)
+		for i, px := range pixels {
+			d := float64(squaredDistance(px, centroids[nearestCentroid(px, centroids)]))
+			distances[i] = d
+			total += d
+		}
+		if total == 0 {
+			// every remaining pixel already sits on a centroid
+			break
+		}
+		target := rnd.Float64() * total
+		next := len(pixels) - 1
+		for i, d := range distances {
+			target -= d
+			if target <= 0 {
+				next = i
+				break
+			}
+		}
+		centroids = append(centroids, pixels[next])
+	}
+	return centroids
+}
+
+func nearestCentroid(px [3]int, centroids [][3]int) int {
+	nearest := 0
+	best := math.MaxInt32
+	for c, centroid := range centroids {
+		if d := squaredDistance(px, centroid); d < best {
+			best = d
+			nearest = c
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"sort"
+)
+
+// medianCutExtractor repeatedly splits the color box with the widest channel range at its median.
+// Boxes of a single color are not split, so the palette can be smaller than paletteSize.
+type medianCutExtractor struct{}
+
+type colorBox struct {
+	pixels [][3]int
+	min    [3]int
+	max    [3]int
+}
+
+func newColorBox(pixels [][3]int) *colorBox {
+	box := &colorBox{
+		pixels: pixels,
+		min:    [3]int{255, 255, 255},
+	}
+	for _, px := range pixels {
+		for ch := 0; ch < 3; ch++ {
+			if px[ch] < box.min[ch] {
+				box.min[ch] = px[ch]
+			}
+			if px[ch] > box.max[ch] {
+				box.max[ch] = px[ch]
+			}
+		}
+	}
+	return box
+}
+
+// widestChannel returns the channel with the largest range and that range.
+func (b *colorBox) widestChannel() (int, int) {
+	channel, width := 0, -1
+	for ch := 0; ch < 3; ch++ {
+		if w := b.max[ch] - b.min[ch]; w > width {
+			channel, width = ch, w
+		}
+	}
+	return channel, width
+}
+
+func (b *colorBox) average() [3]int {
+	var sum [3]int
+	for _, px := range b.pixels {
+		sum[0] += px[0]
+		sum[1] += px[1]
+		sum[2] += px[2]
+	}
+	n := len(b.pixels)
+	return [3]int{(sum[0] + n/2) / n, (sum[1] + n/2) / n, (sum[2] + n/2) / n}
+}
+
+// splitIndex returns the index nearest the median where the value of channel ch changes in pixels,
+// sorted by that channel, so runs of equal values stay in one box. The box must have a range on ch.
+func splitIndex(pixels [][3]int, ch int) int {
+	median := len(pixels) / 2
+	for d := 0; d < len(pixels); d++ {
+		if i := median - d; i > 0 && pixels[i-1][ch] != pixels[i][ch] {
+			return i
+		}
+		if i := median + d; i < len(pixels) && pixels[i-1][ch] != pixels[i][ch] {
+			return i
+		}
+	}
+	return median
+}
+
+func (medianCutExtractor) Extract(pixels [][3]int, paletteSize int) ([][3]int, error) {
+	if len(pixels) == 0 {
+		return nil, ErrNoPixels
+	}
+	// work on a copy since boxes sort their pixels in place
+	owned := make([][3]int, len(pixels))
+	copy(owned, pixels)
+
+	boxes := []*colorBox{newColorBox(owned)}
+	for len(boxes) < paletteSize {
+		target, targetWidth := -1, 0
+		for i, box := range boxes {
+			if len(box.pixels) < 2 {
+				continue
+			}
+			if _, w := box.widestChannel(); w > targetWidth {
+				target, targetWidth = i, w
+			}
+		}
+		if target < 0 {
+			// nothing left to split
+			break
+		}
+
+		box := boxes[target]
+		ch, _ := box.widestChannel()
+		sort.Slice(box.pixels, func(i, j int) bool {
+			return box.pixels[i][ch] < box.pixels[j][ch]
+		})
+		split := splitIndex(box.pixels, ch)
+		boxes[target] = newColorBox(box.pixels[:split])
+		boxes = append(boxes, newColorBox(box.pixels[split:]))
+	}
+
+	palette := make([][3]int, 0, len(boxes))
+	for _, box := range boxes {
+		palette = append(palette, box.average())
+	}
+	return palette, nil
+}
@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"sort"
+)
+
+const octreeDepth = 8
+
+// octreeExtractor builds a full-depth color octree and merges the least populated
+// nodes bottom-up until no more than paletteSize leaves remain.
+type octreeExtractor struct{}
+
+type octreeNode struct {
+	children [8]*octreeNode
+	leaf     bool
+	count    int
+	sum      [3]int
+}
+
+type octree struct {
+	root   *octreeNode
+	levels [octreeDepth][]*octreeNode
+	leaves int
+}
+
+func (t *octree) insert(px [3]int) {
+	node := t.root
+	for level := 0; level < octreeDepth; level++ {
+		shift := uint(octreeDepth - 1 - level)
+		idx := ((px[0]>>shift)&1)<<2 | ((px[1]>>shift)&1)<<1 | (px[2]>>shift)&1
+		child := node.children[idx]
+		if child == nil {
+			child = &octreeNode{}
+			node.children[idx] = child
+			if level == octreeDepth-1 {
+				child.leaf = true
+				t.leaves++
+			} else {
+				t.levels[level+1] = append(t.levels[level+1], child)
+			}
+		}
+		node = child
+	}
+	node.count++
+	node.sum[0] += px[0]
+	node.sum[1] += px[1]
+	node.sum[2] += px[2]
+}
+
+// reduce folds the children of node into node, turning it into a leaf.
+func (t *octree) reduce(node *octreeNode) {
+	merged := 0
+	for i, child := range node.children {
+		if child == nil {
+			continue
+		}
+		node.count += child.count
+		node.sum[0] += child.sum[0]
+		node.sum[1] += child.sum[1]
+		node.sum[2] += child.sum[2]
+		node.children[i] = nil
+		merged++
+	}
+	node.leaf = true
+	t.leaves -= merged - 1
+}
+
+func (n *octreeNode) subtreeCount() int {
+	if n.leaf {
+		return n.count
+	}
+	total := 0
+	for _, child := range n.children {
+		if child != nil {
+			total += child.subtreeCount()
+		}
+	}
+	return total
+}
+
+func (t *octree) collect(node *octreeNode, palette [][3]int) [][3]int {
+	if node.leaf {
+		if node.count > 0 {
+			palette = append(palette, [3]int{
+				(node.sum[0] + node.count/2) / node.count,
+				(node.sum[1] + node.count/2) / node.count,
+				(node.sum[2] + node.count/2) / node.count,
+			})
+		}
+		return palette
+	}
+	for _, child := range node.children {
+		if child != nil {
+			palette = t.collect(child, palette)
+		}
+	}
+	return palette
+}
+
+func (octreeExtractor) Extract(pixels [][3]int, paletteSize int) ([][3]int, error) {
+	if len(pixels) == 0 {
+		return nil, ErrNoPixels
+	}
+	t := &octree{root: &octreeNode{}}
+	t.levels[0] = []*octreeNode{t.root}
+	for _, px := range pixels {
+		t.insert(px)
+	}
+
+	// reduce the deepest level first so that every merged child is already a leaf
+	for level := octreeDepth - 1; level >= 0 && t.leaves > paletteSize; level-- {
+		nodes := t.levels[level]
+		counts := make(map[*octreeNode]int, len(nodes))
+		for _, node := range nodes {
+			counts[node] = node.subtreeCount()
+		}
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return counts[nodes[i]] < counts[nodes[j]]
+		})
+		for _, node := range nodes {
+			if t.leaves <= paletteSize {
+				break
+			}
+			t.reduce(node)
+		}
+	}
+	return t.collect(t.root, nil), nil
+}
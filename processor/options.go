@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorname"
+)
+
+// Validate checks opts without touching the video or any file, so callers can reject a request
+// before fetching its source. Color name and brand palette files are only read by ExtractFunc.
+func (opts Options) Validate() error {
+	if _, err := newPaletteExtractor(opts); err != nil {
+		return fmt.Errorf("action=validateOptions err=%v", err)
+	}
+	if opts.PaletteSize < 1 {
+		return fmt.Errorf("action=validateOptions palette_size=%v err=palette size should be greater than 0", opts.PaletteSize)
+	}
+	if err := validSegmentation(opts.Segmentation); err != nil {
+		return err
+	}
+	if opts.Segmentation != SegmentationScene && opts.PeriodDuration <= 0 {
+		return fmt.Errorf("action=validateOptions period_duration=%v err=period duration should be greater than 0", opts.PeriodDuration)
+	}
+	if opts.SceneThreshold < 0 || opts.SceneThreshold > 1 {
+		return fmt.Errorf("action=validateOptions scene_threshold=%v err=scene threshold should be within 0 and 1", opts.SceneThreshold)
+	}
+	if opts.MinSceneDuration < 0 {
+		return fmt.Errorf("action=validateOptions min_scene_duration=%v err=min scene duration should not be negative", opts.MinSceneDuration)
+	}
+	if opts.FramesPerSegment < AllFramesPerSegment {
+		return fmt.Errorf("action=validateOptions frames_per_segment=%v err=frames per segment should be %v or more", opts.FramesPerSegment, AllFramesPerSegment)
+	}
+	if _, err := newFrameScaler(opts); err != nil {
+		return err
+	}
+	if _, _, err := brandThresholds(opts); err != nil {
+		return err
+	}
+	if opts.VisualizeFolder != "" {
+		if err := opts.Composite.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// colorNames returns ColorNamesDictionary, or the dictionary ColorNames selects.
+func (opts Options) colorNames() (*colorname.Dictionary, error) {
+	if opts.ColorNamesDictionary != nil {
+		return opts.ColorNamesDictionary, nil
+	}
+	return colorname.Open(opts.ColorNames)
+}
@@ -0,0 +1,46 @@
+package processor
+
+import "testing"
+
+func TestOptionsValidate(t *testing.T) {
+	valid := Options{PeriodDuration: 10, PaletteSize: 5}
+	tests := []struct {
+		name    string
+		modify  func(opts *Options)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(opts *Options) {}},
+		{name: "no palette size", modify: func(opts *Options) { opts.PaletteSize = 0 }, wantErr: true},
+		{name: "unknown extractor", modify: func(opts *Options) { opts.Extractor = "dbscan" }, wantErr: true},
+		{name: "unknown color space", modify: func(opts *Options) { opts.ColorSpace = "cmyk" }, wantErr: true},
+		{name: "known extractor and space", modify: func(opts *Options) { opts.Extractor, opts.ColorSpace = ExtractorKMeans, ColorSpaceOKLab }},
+		{name: "unknown segmentation", modify: func(opts *Options) { opts.Segmentation = "shots" }, wantErr: true},
+		{name: "no period", modify: func(opts *Options) { opts.PeriodDuration = 0 }, wantErr: true},
+		{name: "scenes need no period", modify: func(opts *Options) { opts.PeriodDuration, opts.Segmentation = 0, SegmentationScene }},
+		{name: "scene threshold above 1", modify: func(opts *Options) { opts.SceneThreshold = 1.5 }, wantErr: true},
+		{name: "negative scene threshold", modify: func(opts *Options) { opts.SceneThreshold = -0.1 }, wantErr: true},
+		{name: "negative min scene duration", modify: func(opts *Options) { opts.MinSceneDuration = -1 }, wantErr: true},
+		{name: "all frames", modify: func(opts *Options) { opts.FramesPerSegment = AllFramesPerSegment }},
+		{name: "frames below -1", modify: func(opts *Options) { opts.FramesPerSegment = -2 }, wantErr: true},
+		{name: "scale factor above 1", modify: func(opts *Options) { opts.ScaleFactor = 1.5 }, wantErr: true},
+		{name: "scale factor and max pixels", modify: func(opts *Options) { opts.ScaleFactor, opts.MaxPixels = 0.5, 1000 }, wantErr: true},
+		{name: "negative max pixels", modify: func(opts *Options) { opts.MaxPixels = -1 }, wantErr: true},
+		{name: "unknown interpolation", modify: func(opts *Options) { opts.Interpolation = "bicubic" }, wantErr: true},
+		{name: "brand min score above 1", modify: func(opts *Options) { opts.BrandMinScore = threshold(2) }, wantErr: true},
+		{name: "negative brand max delta e", modify: func(opts *Options) { opts.BrandMaxDeltaE = threshold(-1) }, wantErr: true},
+		{name: "zero brand thresholds", modify: func(opts *Options) { opts.BrandMaxDeltaE, opts.BrandMinScore = threshold(0), threshold(0) }},
+		{name: "bad composite without folder", modify: func(opts *Options) { opts.Composite.Layout = "above" }},
+		{name: "bad composite layout", modify: func(opts *Options) { opts.VisualizeFolder, opts.Composite.Layout = "out", "above" }, wantErr: true},
+		{name: "bad composite quality", modify: func(opts *Options) { opts.VisualizeFolder, opts.Composite.Quality = "out", 101 }, wantErr: true},
+		{name: "brand palette file is not read", modify: func(opts *Options) { opts.BrandPalette = "/does/not/exist.csv" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid
+			tt.modify(&opts)
+			if err := opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err=%v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// resultFile is an output file whose content is only kept when the run succeeds.
+// In append mode new rows go to the end of the file and are truncated away on rollback.
+// Otherwise the content is written to a temporary file that replaces path on commit.
+type resultFile struct {
+	*os.File
+	path        string
+	appendMode  bool
+	created     bool
+	initialSize int64
+}
+
+func openResultFile(path string, appendMode bool) (*resultFile, error) {
+	if !appendMode {
+		f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+		if err != nil {
+			return nil, fmt.Errorf("action=openResultFile result_file=%v err=%v", path, err)
+		}
+		return &resultFile{File: f, path: path}, nil
+	}
+
+	_, statErr := os.Stat(path)
+	created := os.IsNotExist(statErr)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("action=openResultFile result_file=%v err=%v", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("action=openResultFile result_file=%v err=%v", path, err)
+	}
+	return &resultFile{
+		File:        f,
+		path:        path,
+		appendMode:  true,
+		created:     created,
+		initialSize: info.Size(),
+	}, nil
+}
+
+// commit keeps what was written.
+func (r *resultFile) commit() error {
+	if err := r.File.Close(); err != nil {
+		return fmt.Errorf("action=resultFile.commit result_file=%v err=%v", r.path, err)
+	}
+	if r.appendMode {
+		return nil
+	}
+	if err := os.Rename(r.File.Name(), r.path); err != nil {
+		return fmt.Errorf("action=resultFile.commit result_file=%v err=%v", r.path, err)
+	}
+	return nil
+}
+
+// rollback leaves path as it was before the run.
+func (r *resultFile) rollback() {
+	switch {
+	case !r.appendMode:
+		r.File.Close()
+		os.Remove(r.File.Name())
+	case r.created:
+		r.File.Close()
+		os.Remove(r.path)
+	default:
+		if err := r.File.Truncate(r.initialSize); err != nil {
+			log.Printf("action=resultFile.rollback result_file=%v err=%v", r.path, err)
+		}
+		r.File.Close()
+	}
+}
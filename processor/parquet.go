@@ -0,0 +1,247 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetRowGroupSize is the row group size handed to the parquet writer.
+const parquetRowGroupSize = 128 * 1024 * 1024
+
+// parquetRow is the flat parquet schema: one row per palette color, with the same columns as Result.
+type parquetRow struct {
+	SourceSerial          string   `parquet:"name=source_serial, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SourceURL             string   `parquet:"name=source_url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SourceDurationSeconds float64  `parquet:"name=source_duration_seconds, type=DOUBLE"`
+	SourceFPS             float64  `parquet:"name=source_fps, type=DOUBLE"`
+	SampleID              string   `parquet:"name=sample_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SampleNumber          int32    `parquet:"name=sample_number, type=INT32"`
+	SampleDuration        float64  `parquet:"name=sample_duration, type=DOUBLE"`
+	PaletteID             string   `parquet:"name=palette_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PaletteCounts         int32    `parquet:"name=palette_counts, type=INT32"`
+	R                     int32    `parquet:"name=r, type=INT32, convertedtype=UINT_16"`
+	G                     int32    `parquet:"name=g, type=INT32, convertedtype=UINT_16"`
+	B                     int32    `parquet:"name=b, type=INT32, convertedtype=UINT_16"`
+	A                     int32    `parquet:"name=a, type=INT32, convertedtype=UINT_16"`
+	RNorm                 float64  `parquet:"name=r_norm, type=DOUBLE"`
+	GNorm                 float64  `parquet:"name=g_norm, type=DOUBLE"`
+	BNorm                 float64  `parquet:"name=b_norm, type=DOUBLE"`
+	Weight                float64  `parquet:"name=weight, type=DOUBLE"`
+	SampleStartMs         int64    `parquet:"name=sample_start_ms, type=INT64"`
+	SampleEndMs           int64    `parquet:"name=sample_end_ms, type=INT64"`
+	FrameMs               int64    `parquet:"name=frame_ms, type=INT64"`
+	R8                    int32    `parquet:"name=r8, type=INT32, convertedtype=UINT_8"`
+	G8                    int32    `parquet:"name=g8, type=INT32, convertedtype=UINT_8"`
+	B8                    int32    `parquet:"name=b8, type=INT32, convertedtype=UINT_8"`
+	Hex                   string   `parquet:"name=hex, type=BYTE_ARRAY, convertedtype=UTF8"`
+	HSVH                  float64  `parquet:"name=hsv_h, type=DOUBLE"`
+	HSVS                  float64  `parquet:"name=hsv_s, type=DOUBLE"`
+	HSVV                  float64  `parquet:"name=hsv_v, type=DOUBLE"`
+	HSLH                  float64  `parquet:"name=hsl_h, type=DOUBLE"`
+	HSLS                  float64  `parquet:"name=hsl_s, type=DOUBLE"`
+	HSLL                  float64  `parquet:"name=hsl_l, type=DOUBLE"`
+	LabL                  float64  `parquet:"name=lab_l, type=DOUBLE"`
+	LabA                  float64  `parquet:"name=lab_a, type=DOUBLE"`
+	LabB                  float64  `parquet:"name=lab_b, type=DOUBLE"`
+	LChC                  float64  `parquet:"name=lch_c, type=DOUBLE"`
+	LChH                  float64  `parquet:"name=lch_h, type=DOUBLE"`
+	ColorName             string   `parquet:"name=color_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ColorNameDistance     float64  `parquet:"name=color_name_distance, type=DOUBLE"`
+	BrandColor            string   `parquet:"name=brand_color, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BrandHex              string   `parquet:"name=brand_hex, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BrandDeltaE           *float64 `parquet:"name=brand_delta_e, type=DOUBLE, repetitiontype=OPTIONAL"`
+	BrandCompliant        *bool    `parquet:"name=brand_compliant, type=BOOLEAN, repetitiontype=OPTIONAL"`
+	BrandScore            *float64 `parquet:"name=brand_score, type=DOUBLE, repetitiontype=OPTIONAL"`
+	BrandViolation        *bool    `parquet:"name=brand_violation, type=BOOLEAN, repetitiontype=OPTIONAL"`
+}
+
+func newParquetRow(r *Result) *parquetRow {
+	return &parquetRow{
+		SourceSerial:          r.SourceSerial,
+		SourceURL:             r.SourceURL,
+		SourceDurationSeconds: r.SourceDurationSeconds,
+		SourceFPS:             r.SourceFPS,
+		SampleID:              r.SampleID,
+		SampleNumber:          int32(r.SampleNumber),
+		SampleDuration:        r.SampleDuration,
+		PaletteID:             r.PaletteID,
+		PaletteCounts:         int32(r.PaletteCounts),
+		R:                     int32(r.R),
+		G:                     int32(r.G),
+		B:                     int32(r.B),
+		A:                     int32(r.A),
+		RNorm:                 r.RNorm,
+		GNorm:                 r.GNorm,
+		BNorm:                 r.BNorm,
+		Weight:                r.Weight,
+		SampleStartMs:         r.SampleStartMs,
+		SampleEndMs:           r.SampleEndMs,
+		FrameMs:               r.FrameMs,
+		R8:                    int32(r.R8),
+		G8:                    int32(r.G8),
+		B8:                    int32(r.B8),
+		Hex:                   r.Hex,
+		HSVH:                  r.HSVH,
+		HSVS:                  r.HSVS,
+		HSVV:                  r.HSVV,
+		HSLH:                  r.HSLH,
+		HSLS:                  r.HSLS,
+		HSLL:                  r.HSLL,
+		LabL:                  r.LabL,
+		LabA:                  r.LabA,
+		LabB:                  r.LabB,
+		LChC:                  r.LChC,
+		LChH:                  r.LChH,
+		ColorName:             r.ColorName,
+		ColorNameDistance:     r.ColorNameDistance,
+		BrandColor:            r.BrandColor,
+		BrandHex:              r.BrandHex,
+		BrandDeltaE:           r.BrandDeltaE,
+		BrandCompliant:        r.BrandCompliant,
+		BrandScore:            r.BrandScore,
+		BrandViolation:        r.BrandViolation,
+	}
+}
+
+// parquetSegmentRow is the nested parquet schema: one row per segment holding its palette as a list.
+type parquetSegmentRow struct {
+	SourceSerial          string         `parquet:"name=source_serial, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SourceURL             string         `parquet:"name=source_url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SourceDurationSeconds float64        `parquet:"name=source_duration_seconds, type=DOUBLE"`
+	SourceFPS             float64        `parquet:"name=source_fps, type=DOUBLE"`
+	SampleID              string         `parquet:"name=sample_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SampleNumber          int32          `parquet:"name=sample_number, type=INT32"`
+	SampleDuration        float64        `parquet:"name=sample_duration, type=DOUBLE"`
+	SampleStartMs         int64          `parquet:"name=sample_start_ms, type=INT64"`
+	SampleEndMs           int64          `parquet:"name=sample_end_ms, type=INT64"`
+	FrameMs               int64          `parquet:"name=frame_ms, type=INT64"`
+	PaletteID             string         `parquet:"name=palette_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BrandScore            *float64       `parquet:"name=brand_score, type=DOUBLE, repetitiontype=OPTIONAL"`
+	BrandViolation        *bool          `parquet:"name=brand_violation, type=BOOLEAN, repetitiontype=OPTIONAL"`
+	Colors                []parquetColor `parquet:"name=colors, type=LIST"`
+}
+
+type parquetColor struct {
+	R              int32    `parquet:"name=r, type=INT32, convertedtype=UINT_8"`
+	G              int32    `parquet:"name=g, type=INT32, convertedtype=UINT_8"`
+	B              int32    `parquet:"name=b, type=INT32, convertedtype=UINT_8"`
+	Hex            string   `parquet:"name=hex, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Weight         float64  `parquet:"name=weight, type=DOUBLE"`
+	HSVH           float64  `parquet:"name=hsv_h, type=DOUBLE"`
+	HSVS           float64  `parquet:"name=hsv_s, type=DOUBLE"`
+	HSVV           float64  `parquet:"name=hsv_v, type=DOUBLE"`
+	HSLH           float64  `parquet:"name=hsl_h, type=DOUBLE"`
+	HSLS           float64  `parquet:"name=hsl_s, type=DOUBLE"`
+	HSLL           float64  `parquet:"name=hsl_l, type=DOUBLE"`
+	LabL           float64  `parquet:"name=lab_l, type=DOUBLE"`
+	LabA           float64  `parquet:"name=lab_a, type=DOUBLE"`
+	LabB           float64  `parquet:"name=lab_b, type=DOUBLE"`
+	LChC           float64  `parquet:"name=lch_c, type=DOUBLE"`
+	LChH           float64  `parquet:"name=lch_h, type=DOUBLE"`
+	Name           string   `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	NameDistance   float64  `parquet:"name=name_distance, type=DOUBLE"`
+	BrandColor     string   `parquet:"name=brand_color, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BrandHex       string   `parquet:"name=brand_hex, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BrandDeltaE    *float64 `parquet:"name=brand_delta_e, type=DOUBLE, repetitiontype=OPTIONAL"`
+	BrandCompliant *bool    `parquet:"name=brand_compliant, type=BOOLEAN, repetitiontype=OPTIONAL"`
+}
+
+func newParquetSegmentRow(s *Segment) *parquetSegmentRow {
+	record := s.Record()
+	row := &parquetSegmentRow{
+		SourceSerial:          s.Video.Serial,
+		SourceURL:             s.Video.URL,
+		SourceDurationSeconds: s.Video.DurationSeconds,
+		SourceFPS:             s.Video.FPS,
+		SampleID:              record.ID,
+		SampleNumber:          int32(record.Number),
+		SampleDuration:        record.Duration,
+		SampleStartMs:         record.StartMs,
+		SampleEndMs:           record.EndMs,
+		FrameMs:               record.FrameMs,
+		PaletteID:             record.PaletteID,
+	}
+	if record.Brand != nil {
+		row.BrandScore = &record.Brand.Score
+		row.BrandViolation = &record.Brand.Violation
+	}
+	for _, clr := range record.Colors {
+		pc := parquetColor{
+			R:            int32(clr.R),
+			G:            int32(clr.G),
+			B:            int32(clr.B),
+			Hex:          clr.Hex,
+			Weight:       clr.Weight,
+			HSVH:         clr.HSV.H,
+			HSVS:         clr.HSV.S,
+			HSVV:         clr.HSV.V,
+			HSLH:         clr.HSL.H,
+			HSLS:         clr.HSL.S,
+			HSLL:         clr.HSL.L,
+			LabL:         clr.Lab.L,
+			LabA:         clr.Lab.A,
+			LabB:         clr.Lab.B,
+			LChC:         clr.LCh.C,
+			LChH:         clr.LCh.H,
+			Name:         clr.Name,
+			NameDistance: clr.NameDistance,
+		}
+		if clr.Brand != nil {
+			pc.BrandColor = clr.Brand.Name
+			pc.BrandHex = clr.Brand.Hex
+			pc.BrandDeltaE = &clr.Brand.DeltaE
+			pc.BrandCompliant = &clr.Brand.Compliant
+		}
+		row.Colors = append(row.Colors, pc)
+	}
+	return row
+}
+
+type parquetSink struct {
+	pw     *writer.ParquetWriter
+	nested bool
+}
+
+// NewParquetSink writes snappy compressed parquet. Flat sinks write one row per palette color
+// like the csv, nested sinks one row per segment with a colors list.
+func NewParquetSink(w io.Writer, nested bool) (Sink, error) {
+	var schema interface{} = new(parquetRow)
+	if nested {
+		schema = new(parquetSegmentRow)
+	}
+	pw, err := writer.NewParquetWriterFromWriter(w, schema, 1)
+	if err != nil {
+		return nil, fmt.Errorf("action=newParquetSink nested=%v err=%v", nested, err)
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &parquetSink{pw: pw, nested: nested}, nil
+}
+
+func (p *parquetSink) Write(segment *Segment) error {
+	if p.nested {
+		err := p.pw.Write(newParquetSegmentRow(segment))
+		if err != nil {
+			return fmt.Errorf("action=parquetSink.write segment=%v err=%v", segment.Number, err)
+		}
+		return nil
+	}
+	for _, result := range segment.Results() {
+		err := p.pw.Write(newParquetRow(result))
+		if err != nil {
+			return fmt.Errorf("action=parquetSink.write segment=%v err=%v", segment.Number, err)
+		}
+	}
+	return nil
+}
+
+func (p *parquetSink) Close() error {
+	err := p.pw.WriteStop()
+	if err != nil {
+		return fmt.Errorf("action=parquetSink.close err=%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// memoryFile reads parquet written to a buffer.
+type memoryFile struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (f *memoryFile) Open(string) (source.ParquetFile, error) {
+	return &memoryFile{Reader: bytes.NewReader(f.data), data: f.data}, nil
+}
+
+func (f *memoryFile) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("read only")
+}
+
+func (f *memoryFile) Write([]byte) (int, error) {
+	return 0, errors.New("read only")
+}
+
+func (f *memoryFile) Close() error {
+	return nil
+}
+
+// readParquet reads every row of data into rows, a pointer to a slice of the row type.
+func readParquet(t *testing.T, data []byte, schema interface{}, rows interface{}) {
+	t.Helper()
+	pr, err := reader.NewParquetReader(&memoryFile{Reader: bytes.NewReader(data), data: data}, schema, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+	if err := pr.Read(rows); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParquetSink(t *testing.T) {
+	var out bytes.Buffer
+	sink, err := NewSink(FormatParquet, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSegments(t, sink, sinkSegments())
+
+	rows := make([]parquetRow, 3)
+	readParquet(t, out.Bytes(), new(parquetRow), &rows)
+	tests := []struct {
+		sampleNumber int32
+		hex          string
+		frameMs      int64
+	}{
+		{sampleNumber: 1, hex: "#ff0000", frameMs: 40},
+		{sampleNumber: 2, hex: "#ff0000", frameMs: 10000},
+		{sampleNumber: 2, hex: "#0000ff", frameMs: 10000},
+	}
+	for i, tt := range tests {
+		row := rows[i]
+		if row.SampleNumber != tt.sampleNumber || row.Hex != tt.hex || row.FrameMs != tt.frameMs || row.SourceSerial != "a" {
+			t.Errorf("row %v=%+v, want %+v", i, row, tt)
+		}
+		// no brand palette, so the optional brand columns are null
+		if row.BrandDeltaE != nil || row.BrandCompliant != nil || row.BrandScore != nil || row.BrandViolation != nil {
+			t.Errorf("row %v has brand values without a brand palette", i)
+		}
+	}
+}
+
+func TestNestedParquetSink(t *testing.T) {
+	path := writeBrandPalette(t, "brand red,#ff0000\n")
+	checker, err := newBrandChecker(Options{BrandPalette: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments := sinkSegments()
+	for _, segment := range segments {
+		checker.check(segment)
+	}
+
+	var out bytes.Buffer
+	sink, err := NewSink(FormatParquetNested, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSegments(t, sink, segments)
+
+	rows := make([]parquetSegmentRow, 2)
+	readParquet(t, out.Bytes(), new(parquetSegmentRow), &rows)
+	if rows[0].SampleNumber != 1 || len(rows[0].Colors) != 1 || rows[1].SampleNumber != 2 || len(rows[1].Colors) != 2 {
+		t.Fatalf("rows=%+v, want segment 1 with 1 color and segment 2 with 2 colors", rows)
+	}
+	second := rows[1]
+	if second.SampleStartMs != 10000 || second.SampleEndMs != 20000 || second.Colors[1].Hex != "#0000ff" {
+		t.Errorf("second row=%+v", second)
+	}
+	if second.BrandScore == nil || *second.BrandScore != 0.75 || second.BrandViolation == nil || !*second.BrandViolation {
+		t.Errorf("second row brand score=%v violation=%v, want 0.75 and a violation", second.BrandScore, second.BrandViolation)
+	}
+	blue := second.Colors[1]
+	if blue.BrandColor != "brand red" || blue.BrandCompliant == nil || *blue.BrandCompliant || blue.BrandDeltaE == nil {
+		t.Errorf("blue brand=%v compliant=%v delta_e=%v, want an off brand match", blue.BrandColor, blue.BrandCompliant, blue.BrandDeltaE)
+	}
+}
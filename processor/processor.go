@@ -2,10 +2,9 @@ package processor
 
 
 import (
+	"context"
 	"fmt"
 	"image"
-	"image/color"
-	"image/draw"
 	"image/png"
 	"log"
 	"math"
@@ -14,232 +13,345 @@ import (
 
 	"github.com/kennykarnama/video-color-palette-generator/ffprobe"
 
-	"github.com/gocarina/gocsv"
-
 	"time"
 
-	gim "github.com/ozankasikci/go-image-merge"
 	"github.com/satori/go.uuid"
-	"gocv.io/x/gocv"
-	ct "github.com/kennykarnama/color-thief"
 )
 
 
-func Run(args Parameter) error {
+// Run extracts palettes according to args and writes them to the result file.
+// csv and jsonl results are appended, json and parquet results replace the file.
+// When extraction fails or ctx is canceled, the result file is left as it was before the run.
+func Run(ctx context.Context, args Parameter) error {
 
 	videoFilePath := args.InputFile
 
-	segmentDurationSeconds := args.PeriodDuration
-
-	paletteSize := args.PaletteSize
-
 	resultFilePath := args.CsvResult
 
-	outputFolder := ""
+	opts := args.Options
 
 	if args.VisualizeCmd != nil {
-		outputFolder = args.VisualizeCmd.OutputFolder
+		opts.VisualizeFolder = args.VisualizeCmd.OutputFolder
+		opts.Composite = CompositeOptions{
+			Layout:     args.VisualizeCmd.Layout,
+			SwatchSize: args.VisualizeCmd.SwatchSize,
+			Labels:     args.VisualizeCmd.Labels,
+			Format:     args.VisualizeCmd.ImageFormat,
+			Quality:    args.VisualizeCmd.Quality,
+		}
+	}
 
-		os.MkdirAll(outputFolder, os.ModePerm)
+	// validate the options and format before touching the result file
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateFormat(args.Format); err != nil {
+		return err
 	}
 
-	vc, err := gocv.VideoCaptureFile(videoFilePath)
+	f, err := openResultFile(resultFilePath, AppendableFormat(args.Format))
 	if err != nil {
-		return fmt.Errorf("action=run.video_capture_file path=%v err=%v", videoFilePath, err)
+		return fmt.Errorf("action=run.open_result_file path=%v result_file=%v err=%v", videoFilePath, resultFilePath, err)
 	}
-	defer vc.Close()
 
-	prober, err := ffprobe.NewFfprobe(videoFilePath)
+	newSink := NewSink
+	if f.initialSize > 0 {
+		newSink = NewAppendingSink
+	}
+	sink, err := newSink(args.Format, f)
 	if err != nil {
-		return fmt.Errorf("action=run.ffprobe path=%v err=%v", videoFilePath, err)
+		f.rollback()
+		return err
 	}
 
-	videoFps := prober.GetVideoFps()
-	videoDuration := prober.GetDuration().Seconds()
-	videoDurationMs := prober.GetDuration().Milliseconds()
-	frameCountsPerSegment := math.Floor(videoFps * segmentDurationSeconds)
+	timeline := args.VisualizeCmd != nil && (args.VisualizeCmd.Timeline || args.VisualizeCmd.TimelineSVG)
+	var segments []*Segment
+	var report *Report
+	if args.VisualizeCmd != nil && args.VisualizeCmd.Report {
+		report = NewReport(args.VisualizeCmd.ThumbnailWidth)
+	}
 
-	log.Printf("FPS=%v", videoFps)
-	log.Printf("FrameCountsPerSegment=%v", frameCountsPerSegment)
-	log.Printf("durationSeconds=%v", videoDuration)
-	log.Printf("durationMs=%v", videoDurationMs)
+	// the report shows the summary palette even when it is not written to a file
+	var summaryBuilder *SummaryBuilder
+	if args.SummaryResult != "" || report != nil {
+		summaryBuilder, err = NewSummaryBuilder(opts)
+		if err != nil {
+			f.rollback()
+			return err
+		}
+	}
 
-	videoFrame := gocv.NewMat()
-	defer videoFrame.Close()
+	err = ExtractFunc(ctx, videoFilePath, opts, func(segment *Segment) error {
+		if summaryBuilder != nil {
+			summaryBuilder.Add(segment)
+		}
+		if report != nil {
+			if err := report.Add(segment); err != nil {
+				return err
+			}
+		}
+		// frames are only needed for the report thumbnail, do not hold on to them
+		segment.Frame = nil
+		if timeline {
+			segments = append(segments, segment)
+		}
+		return sink.Write(segment)
+	})
+	if err == nil {
+		err = sink.Close()
+	}
+	var summary *Segment
+	if err == nil && summaryBuilder != nil {
+		summary, err = summaryBuilder.Summary()
+	}
+	if err == nil && args.SummaryResult != "" {
+		err = writeSummary(args.SummaryResult, args.Format, summary)
+	}
+	if err == nil && timeline {
+		err = writeTimeline(args.VisualizeCmd, segments)
+	}
+	if err == nil && report != nil {
+		if len(report.segments) > 0 {
+			err = writeReport(args.VisualizeCmd.OutputFolder, report, summary)
+		} else {
+			log.Printf("action=run.writeReport err=no segments, report skipped")
+		}
+	}
+	if err != nil {
+		f.rollback()
+		return err
+	}
+	return f.commit()
+}
 
-	frameCount := float64(0)
-	period := 0
+func writeSummary(summaryFilePath string, format string, summary *Segment) error {
+	f, err := openResultFile(summaryFilePath, false)
+	if err != nil {
+		return err
+	}
+	sink, err := NewSink(format, f)
+	if err == nil && summary != nil {
+		// without summary the file is left empty of segments, like the result
+		err = sink.Write(summary)
+	}
+	if err == nil {
+		err = sink.Close()
+	}
+	if err != nil {
+		f.rollback()
+		return err
+	}
+	return f.commit()
+}
 
-	f, err := os.OpenFile(resultFilePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+// Extract samples the video at videoFilePath and returns every segment with its palette.
+func Extract(ctx context.Context, videoFilePath string, opts Options) ([]*Segment, error) {
+	var segments []*Segment
+	err := ExtractFunc(ctx, videoFilePath, opts, func(segment *Segment) error {
+		segments = append(segments, segment)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("action=run.open_result_file path=%v result_file=%v err=%v", videoFilePath, resultFilePath, err)
+		return nil, err
 	}
-	defer f.Close()
+	return segments, nil
+}
 
-	defer timeTrack(time.Now(), "video-color-palette-extraction")
+// ExtractFunc samples the video at videoFilePath and calls fn for every segment in SampleNumber order.
+// Extraction stops at the first error returned by fn, or with a *CanceledError once ctx is done.
+func ExtractFunc(ctx context.Context, videoFilePath string, opts Options, fn func(segment *Segment) error) error {
 
-	start := time.Now()
+	segmentDurationSeconds := opts.PeriodDuration
 
-	var desiredIdx float64
-	//totalFrames := vc.Get(gocv.VideoCaptureFrameCount)
-	
-	for desiredIdx = float64(0); desiredIdx <= float64(videoDurationMs); desiredIdx += (segmentDurationSeconds * 1000) {
+	paletteSize := opts.PaletteSize
 
-		vc.Set(gocv.VideoCapturePosMsec, desiredIdx)
+	outputFolder := opts.VisualizeFolder
 
-		var tmpImage image.Image
-		var err error
-		var imageExist bool
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	extractor, err := newPaletteExtractor(opts)
+	if err != nil {
+		return fmt.Errorf("action=run.resolve_extractor err=%v", err)
+	}
+	scaler, err := newFrameScaler(opts)
+	if err != nil {
+		return err
+	}
+	names, err := opts.colorNames()
+	if err != nil {
+		return fmt.Errorf("action=run.color_names err=%v", err)
+	}
+	brand, err := newBrandChecker(opts)
+	if err != nil {
+		return fmt.Errorf("action=run.brand_palette err=%v", err)
+	}
+	sceneMode := opts.Segmentation == SegmentationScene
 
-		start = time.Now()
+	if outputFolder != "" {
+		os.MkdirAll(outputFolder, os.ModePerm)
+	}
 
-		for {
-			if ok := vc.Read(&videoFrame); !ok {
-				log.Printf("Video frame closed")
-				break
-			}
-			if videoFrame.Empty() {
-				log.Printf("Video frame empty")
-				continue
-			}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
 
-			imageExist = true
+	prober, err := ffprobe.NewFfprobe(ctx, videoFilePath)
+	if err != nil {
+		if canceledErr := checkCanceled(ctx, 0); canceledErr != nil {
+			return canceledErr
+		}
+		return fmt.Errorf("action=run.ffprobe path=%v err=%v", videoFilePath, err)
+	}
 
-			// scale frame
-			scaledVideoFrame := gocv.NewMat()
-			gocv.Resize(videoFrame, &scaledVideoFrame, image.Point{X: 0, Y: 0}, 0.1, 0.1, gocv.InterpolationCubic)
+	videoFps := prober.GetVideoFps()
+	videoDuration := prober.GetDuration().Seconds()
+	videoDurationMs := prober.GetDuration().Milliseconds()
+	frameCountsPerSegment := math.Floor(videoFps * segmentDurationSeconds)
 
-			// generate palette
-			tmpImage, err = scaledVideoFrame.ToImage()
-			if err != nil {
-				scaledVideoFrame.Close()
-				return fmt.Errorf("action=run.scaledVideoFrameToImage err=%v", err)
+	log.Printf("FPS=%v", videoFps)
+	log.Printf("FrameCountsPerSegment=%v", frameCountsPerSegment)
+	log.Printf("durationSeconds=%v", videoDuration)
+	log.Printf("durationMs=%v", videoDurationMs)
+	log.Printf("workers=%v", workers)
+
+	video := &Video{
+		Serial:          opts.InputSerial,
+		URL:             videoFilePath,
+		DurationSeconds: videoDuration,
+		FPS:             videoFps,
+	}
+
+	defer timeTrack(time.Now(), "video-color-palette-extraction")
+
+	var jobs []segmentJob
+	if sceneMode {
+		detector := &sceneDetector{
+			Threshold:  opts.SceneThreshold,
+			MinSceneMs: opts.MinSceneDuration * 1000,
+		}
+		if detector.Threshold <= 0 {
+			detector.Threshold = DefaultSceneThreshold
+		}
+		jobs, err = detector.detect(ctx, videoFilePath, float64(videoDurationMs))
+		if err != nil {
+			if canceledErr := checkCanceled(ctx, 0); canceledErr != nil {
+				return canceledErr
 			}
-			scaledVideoFrame.Close()
-			break
+			return fmt.Errorf("action=run.detect_scenes path=%v err=%v", videoFilePath, err)
 		}
+		log.Printf("scenes=%v", len(jobs))
+	} else {
+		jobs = fixedJobs(float64(videoDurationMs), segmentDurationSeconds)
+	}
 
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sampler := &segmentSampler{
+		videoFilePath:    videoFilePath,
+		extractor:        extractor,
+		paletteSize:      paletteSize,
+		framesPerSegment: opts.FramesPerSegment,
+		scaler:           scaler,
+		names:            names,
+		keepFrame:        outputFolder != "",
+	}
+	samples := runSamplers(workCtx, sampler, workers, jobs)
 
-		frameCount++
+	// workers finish out of order; hold samples back until every earlier job has been emitted
+	// so that SampleNumber follows the video timeline regardless of the worker count
+	pending := map[int]*segmentSample{}
+	next := 0
+	period := 0
+	var firstErr error
 
-		if imageExist {
+	emit := func(sample *segmentSample) error {
+		frameCount := sample.job.index + 1
+		if !sample.exists {
+			return nil
+		}
 
-			colors, err := ct.GetPalette(tmpImage, int(paletteSize), args.FunctionType)
+		if outputFolder != "" {
+			visualizeFileName := filepath.Join(outputFolder, fmt.Sprintf("visualize_%v__segment_%v%v", frameCount, period+1, opts.Composite.extension()))
+			log.Printf("writing file=%v", visualizeFileName)
+			err := writeComposite(visualizeFileName, sample.frame, sample.colors, opts.Composite)
 			if err != nil {
-				return fmt.Errorf("action=run.GetPalette err=%v", err)
+				return fmt.Errorf("action=run.createVisualizeFile target=%v err=%v", visualizeFileName, err)
 			}
+		}
 
-			if args.VisualizeCmd != nil {
-				frameFileName := filepath.Join(outputFolder, fmt.Sprintf("frame_%v__segment_%v.png", frameCount, period+1))
-				log.Printf("writing file=%v", frameFileName)
-				writeStatus := gocv.IMWriteWithParams(frameFileName, videoFrame, []int{gocv.IMWritePngStrategy})
-				if !writeStatus {
-					return fmt.Errorf("action=run.WriteVideoFrame target=%v err=%v", frameFileName, err)
-				}
-				paletteFileName := filepath.Join(outputFolder, fmt.Sprintf("palette_%v__segment_%v.png", frameCount, period+1))
-				log.Printf("writing palette file=%v", paletteFileName)
-
-				_, err = createPalette(paletteFileName, colors)
-				if err != nil {
-					return fmt.Errorf("action=run.createPaletteFile target=%v err=%v", paletteFileName, err)
-				}
-
-				visualizeFileName := filepath.Join(outputFolder, fmt.Sprintf("visualize_%v__segment_%v.png", frameCount, period+1))
-				// merge frame and palette to allow better visualization
-				err = visualize(frameFileName, paletteFileName, visualizeFileName)
-				if err != nil {
-					return fmt.Errorf("action=run.createVisualizeFile target=%v err=%v", visualizeFileName, err)
-				}
-				os.Remove(frameFileName)
-				os.Remove(paletteFileName)
-			}
+		period++
+		segment := &Segment{
+			Video:     video,
+			ID:        uuid.NewV4().String(),
+			Number:    period,
+			Start:     time.Duration(sample.job.startMs * float64(time.Millisecond)),
+			End:       time.Duration(sample.job.endMs * float64(time.Millisecond)),
+			FrameTime: time.Duration(sample.frameMs * float64(time.Millisecond)),
+			Duration:  segmentDurationSeconds,
+			PaletteID: uuid.NewV4().String(),
+			Colors:    sample.colors,
+			Frame:     sample.frame,
+		}
+		if sceneMode {
+			// scenes have no fixed period, report how long this one actually lasts
+			segment.Duration = (sample.job.endMs - sample.job.startMs) / 1000
+		}
+		brand.check(segment)
+		err := fn(segment)
+		if err != nil {
+			return fmt.Errorf("action=run.emit_segment segment=%v err=%v", period, err)
+		}
+		log.Printf("Segment: %d k=%v took=%s", period, len(sample.colors), sample.elapsed)
+		return nil
+	}
 
-			paletteID := uuid.NewV4().String()
-			period++
-			periodID := uuid.NewV4().String()
-			var results []*Result
-			for _, clr := range colors {
-				result := &Result{
-					SourceURL:             videoFilePath,
-					SourceSerial:          args.InputSerial,
-					SourceDurationSeconds: videoDuration,
-					SourceFPS:             videoFps,
-					SampleID:              periodID,
-					SampleNumber:          period,
-					SampleDuration:        segmentDurationSeconds,
-					PaletteCounts:         len(colors),
-					PaletteID:             paletteID,
-				}
-				result.R, result.G, result.B, result.A = clr.RGBA()
-				result.Normalize16BitRGB()
-				results = append(results, result)
+	for sample := range samples {
+		if firstErr != nil {
+			// drain until the workers have stopped
+			continue
+		}
+		if sample.err != nil {
+			firstErr = sample.err
+			cancel()
+			continue
+		}
+		pending[sample.job.index] = sample
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
 			}
-			if period == 1 {
-				// write csv
-				err = gocsv.MarshalFile(results, f)
-				if err != nil {
-					return fmt.Errorf("action=run.csv_marshal err=%v", err)
-				}
-			}else {
-				// write csv
-				err = gocsv.MarshalWithoutHeaders(results, f)
-				if err != nil {
-					return fmt.Errorf("action=run.csv_marshalWithoutHeaders err=%v", err)
-				}
+			delete(pending, next)
+			next++
+			if err := emit(ready); err != nil {
+				firstErr = err
+				cancel()
+				break
 			}
-			elapsed := time.Since(start)
-			log.Printf("Segment: %d k=%v took=%s", period, len(colors), elapsed)
-
 		}
 	}
-	return nil
-}
 
-
-func createPalette(out string, colors []color.Color) (image.Image, error) {
-	blocks := len(colors)
-	blockw := 1280 / blocks
-	space := 0
-	img := image.NewRGBA(image.Rect(0, 0, blocks*blockw+space*(blocks-1), 4*(blockw+space)))
-
-	for i := 0; i < blocks; i++ {
-		draw.Draw(img, image.Rect(i*(blockw+space), 0, i*(blockw+space)+blockw, blockw), &image.Uniform{colors[i]}, image.Point{}, draw.Src)
+	// a canceled parent context takes precedence over the errors it caused in the workers
+	if err := checkCanceled(ctx, period); err != nil {
+		return err
 	}
-
-	toimg, err := os.Create(out)
-	if err != nil {
-		return nil, fmt.Errorf("action=createPalette out=%v err=%v", out, err)
-	}
-	defer toimg.Close()
-
-	err = png.Encode(toimg, img)
-	if err != nil {
-		return nil, fmt.Errorf("action=createPalette out=%v err=%v", out, err)
-	}
-
-	return img, nil
+	return firstErr
 }
 
-func visualize(videoFramePath string, palettePath string, out string) error {
-	grids := []*gim.Grid{
-		{ImageFilePath: videoFramePath},
-		{ImageFilePath: palettePath},
-	}
-	rgba, err := gim.New(grids, 1, 2).Merge()
-	if err != nil {
-		return fmt.Errorf("action=visualize video_frame_path=%v palette_path=%v err=%v", videoFramePath, palettePath, err)
-	}
+func writePNG(out string, img image.Image) error {
 	f, err := os.Create(out)
 	if err != nil {
-		return fmt.Errorf("action=visualize video_frame_path=%v palette_path=%v err=%v", videoFramePath, palettePath, err)
-	}
-	err = png.Encode(f, rgba)
-	if err != nil {
-		return fmt.Errorf("action=visualize video_frame_path=%v palette_path=%v err=%v", videoFramePath, palettePath, err)
+		return err
 	}
-	return nil
+	defer f.Close()
+	return png.Encode(f, img)
 }
 
+
+
 func timeTrack(start time.Time, name string) {
 	elapsed := time.Since(start)
 	log.Printf("%s took %s", name, elapsed)
@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+// ResultDocument is the json output: segments nested under their video.
+type ResultDocument struct {
+	Video    *VideoRecord     `json:"video"`
+	Segments []*SegmentRecord `json:"segments"`
+}
+
+type VideoRecord struct {
+	Serial          string  `json:"serial"`
+	URL             string  `json:"url"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	FPS             float64 `json:"fps"`
+}
+
+// SegmentRecord is the json form of a Segment. Video is only set on json lines, where every line stands alone.
+type SegmentRecord struct {
+	Video     *VideoRecord  `json:"video,omitempty"`
+	ID        string        `json:"id"`
+	Number    int           `json:"number"`
+	StartMs   int64         `json:"startMs"`
+	EndMs     int64         `json:"endMs"`
+	FrameMs   int64         `json:"frameMs"`
+	Duration  float64       `json:"duration"`
+	PaletteID string        `json:"paletteID"`
+	Colors    []ColorRecord `json:"colors"`
+	Brand     *BrandRecord  `json:"brand,omitempty"`
+}
+
+// BrandRecord is the segment compliance against the brand palette.
+type BrandRecord struct {
+	Score     float64 `json:"score"`
+	Violation bool    `json:"violation"`
+}
+
+type ColorRecord struct {
+	R            uint8             `json:"r"`
+	G            uint8             `json:"g"`
+	B            uint8             `json:"b"`
+	Hex          string            `json:"hex"`
+	Weight       float64           `json:"weight"`
+	HSV          HSVRecord         `json:"hsv"`
+	HSL          HSLRecord         `json:"hsl"`
+	Lab          LabRecord         `json:"lab"`
+	LCh          LChRecord         `json:"lch"`
+	Name         string            `json:"name"`
+	NameDistance float64           `json:"nameDistance"`
+	Brand        *BrandColorRecord `json:"brand,omitempty"`
+}
+
+// BrandColorRecord is the brand color nearest to a palette color.
+type BrandColorRecord struct {
+	Name      string  `json:"name"`
+	Hex       string  `json:"hex"`
+	DeltaE    float64 `json:"deltaE"`
+	Compliant bool    `json:"compliant"`
+}
+
+type HSVRecord struct {
+	H float64 `json:"h"`
+	S float64 `json:"s"`
+	V float64 `json:"v"`
+}
+
+type HSLRecord struct {
+	H float64 `json:"h"`
+	S float64 `json:"s"`
+	L float64 `json:"l"`
+}
+
+type LabRecord struct {
+	L float64 `json:"l"`
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+type LChRecord struct {
+	L float64 `json:"l"`
+	C float64 `json:"c"`
+	H float64 `json:"h"`
+}
+
+func newColorRecord(clr PaletteColor) ColorRecord {
+	c := clr.Color
+	record := ColorRecord{Hex: colorspace.Hex(c), Weight: clr.Weight, Name: clr.Name, NameDistance: clr.NameDistance}
+	record.R, record.G, record.B = colorspace.RGB8(c)
+	record.HSV.H, record.HSV.S, record.HSV.V = colorspace.HSV(c)
+	record.HSL.H, record.HSL.S, record.HSL.L = colorspace.HSL(c)
+	lab := colorspace.ToLab(c)
+	record.Lab = LabRecord{L: lab.L, A: lab.A, B: lab.B}
+	lch := lab.LCh()
+	record.LCh = LChRecord{L: lch.L, C: lch.C, H: lch.H}
+	if clr.Brand != nil {
+		record.Brand = &BrandColorRecord{
+			Name:      clr.Brand.Name,
+			Hex:       clr.Brand.Hex,
+			DeltaE:    clr.Brand.DeltaE,
+			Compliant: clr.Brand.Compliant,
+		}
+	}
+	return record
+}
+
+func (v *Video) Record() *VideoRecord {
+	return &VideoRecord{
+		Serial:          v.Serial,
+		URL:             v.URL,
+		DurationSeconds: v.DurationSeconds,
+		FPS:             v.FPS,
+	}
+}
+
+// Record converts the segment to its json form, without the video.
+func (s *Segment) Record() *SegmentRecord {
+	record := &SegmentRecord{
+		ID:        s.ID,
+		Number:    s.Number,
+		StartMs:   s.Start.Milliseconds(),
+		EndMs:     s.End.Milliseconds(),
+		FrameMs:   s.frameMs(),
+		Duration:  s.Duration,
+		PaletteID: s.PaletteID,
+	}
+	for _, clr := range s.Colors {
+		record.Colors = append(record.Colors, newColorRecord(clr))
+	}
+	if s.Brand != nil {
+		record.Brand = &BrandRecord{Score: s.Brand.Score, Violation: s.Brand.Violation}
+	}
+	return record
+}
@@ -0,0 +1,242 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+const (
+	DefaultReportThumbnailWidth = 320
+	reportThumbnailQuality      = 80
+	reportTimelineWidth         = 1200
+	reportTimelineHeight        = 160
+)
+
+// Report collects segments for a self-contained html page. It keeps a small jpeg thumbnail
+// of each segment frame rather than the frame itself.
+type Report struct {
+	thumbnailWidth int
+	segments       []*Segment
+	thumbnails     []template.URL
+}
+
+// NewReport scales thumbnails down to thumbnailWidth pixels, DefaultReportThumbnailWidth when 0.
+func NewReport(thumbnailWidth int) *Report {
+	if thumbnailWidth <= 0 {
+		thumbnailWidth = DefaultReportThumbnailWidth
+	}
+	return &Report{thumbnailWidth: thumbnailWidth}
+}
+
+// Add accounts for one segment. Segments without a Frame are listed without thumbnail.
+func (r *Report) Add(segment *Segment) error {
+	var thumbnail template.URL
+	if segment.Frame != nil {
+		var buf bytes.Buffer
+		err := jpeg.Encode(&buf, downscale(segment.Frame, r.thumbnailWidth), &jpeg.Options{Quality: reportThumbnailQuality})
+		if err != nil {
+			return fmt.Errorf("action=report.add segment=%v err=%v", segment.Number, err)
+		}
+		thumbnail = template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+	r.segments = append(r.segments, segment)
+	r.thumbnails = append(r.thumbnails, thumbnail)
+	return nil
+}
+
+type reportColor struct {
+	Hex    string
+	Weight string
+	Ink    string
+	Name   string
+	Brand  *BrandMatch
+}
+
+type reportSegment struct {
+	Number    int
+	Start     string
+	End       string
+	Frame     string
+	Thumbnail template.URL
+	Colors    []reportColor
+	// BrandScore is empty without a brand palette.
+	BrandScore string
+	Violation  bool
+}
+
+type reportPage struct {
+	Video    *Video
+	Duration string
+	Timeline template.HTML
+	Summary  *reportSegment
+	Segments []reportSegment
+}
+
+// Write renders the report. summary may be nil.
+func (r *Report) Write(w io.Writer, summary *Segment) error {
+	if len(r.segments) == 0 {
+		return fmt.Errorf("action=report.write err=no segments")
+	}
+	var timeline bytes.Buffer
+	if err := WriteTimelineSVG(&timeline, r.segments, reportTimelineWidth, reportTimelineHeight); err != nil {
+		return fmt.Errorf("action=report.write err=%v", err)
+	}
+	video := r.segments[0].Video
+	page := reportPage{
+		Video:    video,
+		Duration: formatTimestamp(time.Duration(video.DurationSeconds * float64(time.Second))),
+		// the svg is generated above from numbers and hex codes only
+		Timeline: template.HTML(timeline.String()),
+	}
+	if summary != nil {
+		s := newReportSegment(summary, "")
+		page.Summary = &s
+	}
+	for i, segment := range r.segments {
+		page.Segments = append(page.Segments, newReportSegment(segment, r.thumbnails[i]))
+	}
+	if err := reportTemplate.Execute(w, page); err != nil {
+		return fmt.Errorf("action=report.write err=%v", err)
+	}
+	return nil
+}
+
+func newReportSegment(segment *Segment, thumbnail template.URL) reportSegment {
+	s := reportSegment{
+		Number:    segment.Number,
+		Start:     formatTimestamp(segment.Start),
+		End:       formatTimestamp(segment.End),
+		Frame:     "none",
+		Thumbnail: thumbnail,
+	}
+	if segment.FrameTime >= 0 {
+		s.Frame = formatTimestamp(segment.FrameTime)
+	}
+	if segment.Brand != nil {
+		s.BrandScore = fmt.Sprintf("%.0f%%", segment.Brand.Score*100)
+		s.Violation = segment.Brand.Violation
+	}
+	for _, clr := range segment.Colors {
+		ink := "#000"
+		if colorspace.ToLab(clr.Color).L < 55 {
+			ink = "#fff"
+		}
+		s.Colors = append(s.Colors, reportColor{
+			Hex:    colorspace.Hex(clr.Color),
+			Weight: fmt.Sprintf("%.1f%%", clr.Weight*100),
+			Ink:    ink,
+			Name:   clr.Name,
+			Brand:  clr.Brand,
+		})
+	}
+	return s
+}
+
+// writeReport writes report.html to the visualize output folder.
+func writeReport(folder string, report *Report, summary *Segment) error {
+	out := filepath.Join(folder, "report.html")
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("action=writeReport out=%v err=%v", out, err)
+	}
+	err = report.Write(f, summary)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("action=writeReport out=%v err=%v", out, err)
+	}
+	return nil
+}
+
+// formatTimestamp formats d as m:ss.mmm, or h:mm:ss.mmm from one hour on.
+func formatTimestamp(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	s := (d % time.Minute) / time.Second
+	ms := (d % time.Second) / time.Millisecond
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d.%03d", h, m, s, ms)
+	}
+	return fmt.Sprintf("%d:%02d.%03d", m, s, ms)
+}
+
+// downscale shrinks img to at most width pixels wide by averaging whole blocks of pixels.
+func downscale(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	factor := (b.Dx() + width - 1) / width
+	if factor <= 1 {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx()/factor, b.Dy()/factor))
+	n := uint32(factor * factor)
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			var sr, sg, sb uint32
+			for dy := 0; dy < factor; dy++ {
+				for dx := 0; dx < factor; dx++ {
+					r, g, bl, _ := img.At(b.Min.X+x*factor+dx, b.Min.Y+y*factor+dy).RGBA()
+					sr, sg, sb = sr+r, sg+g, sb+bl
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(sr / n >> 8), G: uint8(sg / n >> 8), B: uint8(sb / n >> 8), A: 0xff})
+		}
+	}
+	return dst
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Color palette report {{.Video.Serial}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 24px; color: #222; background: #fafafa; }
+h1 { font-size: 20px; margin-bottom: 4px; }
+.meta { color: #666; font-size: 13px; margin-bottom: 16px; word-break: break-all; }
+.timeline svg { width: 100%; height: auto; display: block; border: 1px solid #ddd; }
+.palette { display: flex; min-height: 56px; }
+.swatch { flex: 1; padding: 4px 6px; font: 12px/1.4 ui-monospace, Menlo, Consolas, monospace; overflow: hidden; }
+.segments { display: grid; grid-template-columns: repeat(auto-fill, minmax(320px, 1fr)); gap: 16px; margin-top: 16px; }
+.segment { background: #fff; border: 1px solid #ddd; border-radius: 4px; overflow: hidden; }
+.segment img { width: 100%; display: block; }
+.segment .head { padding: 6px 8px; font-size: 13px; display: flex; justify-content: space-between; }
+.violation { border-color: #d33; }
+.violation .head { background: #fdecea; }
+.summary { max-width: 960px; margin-top: 16px; }
+</style>
+</head>
+<body>
+<h1>Color palette report</h1>
+<div class="meta">{{if .Video.Serial}}{{.Video.Serial}} &middot; {{end}}{{.Video.URL}} &middot; {{.Duration}} &middot; {{printf "%.3g" .Video.FPS}} fps &middot; {{len .Segments}} segments</div>
+<div class="timeline">{{.Timeline}}</div>
+{{with .Summary}}
+<h2>Summary palette</h2>
+<div class="summary">{{template "palette" .}}</div>
+{{end}}
+<h2>Segments</h2>
+<div class="segments">
+{{range .Segments}}
+<div class="segment{{if .Violation}} violation{{end}}" id="segment-{{.Number}}">
+{{if .Thumbnail}}<img src="{{.Thumbnail}}" alt="segment {{.Number}}" loading="lazy">{{end}}
+<div class="head"><span>#{{.Number}} {{.Start}} &ndash; {{.End}}</span><span>frame {{.Frame}}{{with .BrandScore}} &middot; brand {{.}}{{end}}</span></div>
+{{template "palette" .}}
+</div>
+{{end}}
+</div>
+</body>
+</html>
+{{define "palette"}}<div class="palette">{{range .Colors}}<div class="swatch" style="background: {{.Hex}}; color: {{.Ink}}" title="{{.Hex}} {{.Weight}}{{if .Name}} {{.Name}}{{end}}{{with .Brand}} brand {{.Name}} &Delta;E {{printf "%.1f" .DeltaE}}{{end}}">{{.Hex}}<br>{{.Weight}}{{if .Name}}<br>{{.Name}}{{end}}</div>{{end}}</div>{{end}}
+`))
@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+// DefaultScaleFactor is applied when no scale factor, pixel budget or target long edge is given.
+const DefaultScaleFactor = 0.1
+
+var interpolations = map[string]gocv.InterpolationFlags{
+	"nearest":  gocv.InterpolationNearestNeighbor,
+	"linear":   gocv.InterpolationLinear,
+	"cubic":    gocv.InterpolationCubic,
+	"area":     gocv.InterpolationArea,
+	"lanczos4": gocv.InterpolationLanczos4,
+}
+
+// frameScaler shrinks decoded frames before their pixels are clustered.
+// Exactly one of scaleFactor, maxPixels and targetLongEdge drives the size.
+type frameScaler struct {
+	scaleFactor    float64
+	maxPixels      int
+	targetLongEdge int
+	interpolation  gocv.InterpolationFlags
+}
+
+func newFrameScaler(opts Options) (*frameScaler, error) {
+	set := 0
+	for _, isSet := range []bool{opts.ScaleFactor != 0, opts.MaxPixels != 0, opts.TargetLongEdge != 0} {
+		if isSet {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("action=newFrameScaler scale_factor=%v max_pixels=%v target_long_edge=%v err=only one of scale factor, max pixels or target long edge can be set", opts.ScaleFactor, opts.MaxPixels, opts.TargetLongEdge)
+	}
+	if opts.ScaleFactor < 0 || opts.ScaleFactor > 1 {
+		return nil, fmt.Errorf("action=newFrameScaler scale_factor=%v err=scale factor should be in (0, 1]", opts.ScaleFactor)
+	}
+	if opts.MaxPixels < 0 || opts.TargetLongEdge < 0 {
+		return nil, fmt.Errorf("action=newFrameScaler max_pixels=%v target_long_edge=%v err=size limits should be positive", opts.MaxPixels, opts.TargetLongEdge)
+	}
+
+	interpolation := gocv.InterpolationCubic
+	if opts.Interpolation != "" {
+		var ok bool
+		interpolation, ok = interpolations[strings.ToLower(opts.Interpolation)]
+		if !ok {
+			var names []string
+			for name := range interpolations {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("action=newFrameScaler interpolation=%v err=unknown interpolation, available: %v", opts.Interpolation, strings.Join(names, ", "))
+		}
+	}
+
+	scaler := &frameScaler{
+		scaleFactor:    opts.ScaleFactor,
+		maxPixels:      opts.MaxPixels,
+		targetLongEdge: opts.TargetLongEdge,
+		interpolation:  interpolation,
+	}
+	if set == 0 {
+		scaler.scaleFactor = DefaultScaleFactor
+	}
+	return scaler, nil
+}
+
+// factor returns the resize factor for a cols x rows frame. Size limits never upscale.
+func (s *frameScaler) factor(cols, rows int) float64 {
+	factor := s.scaleFactor
+	switch {
+	case s.targetLongEdge > 0:
+		longEdge := cols
+		if rows > longEdge {
+			longEdge = rows
+		}
+		factor = float64(s.targetLongEdge) / float64(longEdge)
+	case s.maxPixels > 0:
+		factor = math.Sqrt(float64(s.maxPixels) / float64(cols*rows))
+	}
+	if factor > 1 {
+		factor = 1
+	}
+	return factor
+}
+
+// targetSize returns the analysis size of a cols x rows frame, at least 1x1:
+// a tiny factor or pixel budget would otherwise round a side down to 0, which gocv.Resize rejects.
+func (s *frameScaler) targetSize(cols, rows int) image.Point {
+	factor := s.factor(cols, rows)
+	size := image.Point{X: int(math.Round(float64(cols) * factor)), Y: int(math.Round(float64(rows) * factor))}
+	if size.X < 1 {
+		size.X = 1
+	}
+	if size.Y < 1 {
+		size.Y = 1
+	}
+	return size
+}
+
+// resize writes the analysis-sized copy of frame into dst.
+func (s *frameScaler) resize(frame gocv.Mat, dst *gocv.Mat) {
+	gocv.Resize(frame, dst, s.targetSize(frame.Cols(), frame.Rows()), 0, 0, s.interpolation)
+}
@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFrameScalerTargetSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		cols, rows int
+		want       image.Point
+	}{
+		{name: "default factor", cols: 1920, rows: 1080, want: image.Point{X: 192, Y: 108}},
+		{name: "scale factor", opts: Options{ScaleFactor: 0.5}, cols: 1920, rows: 1080, want: image.Point{X: 960, Y: 540}},
+		{name: "tiny scale factor", opts: Options{ScaleFactor: 0.0001}, cols: 1920, rows: 1080, want: image.Point{X: 1, Y: 1}},
+		{name: "tiny factor on a thin frame", opts: Options{ScaleFactor: 0.001}, cols: 1920, rows: 100, want: image.Point{X: 2, Y: 1}},
+		{name: "max pixels", opts: Options{MaxPixels: 192 * 108}, cols: 1920, rows: 1080, want: image.Point{X: 192, Y: 108}},
+		{name: "one pixel budget", opts: Options{MaxPixels: 1}, cols: 1920, rows: 1080, want: image.Point{X: 1, Y: 1}},
+		{name: "pixel budget on a thin frame", opts: Options{MaxPixels: 100}, cols: 10000, rows: 10, want: image.Point{X: 316, Y: 1}},
+		{name: "max pixels never upscales", opts: Options{MaxPixels: 1 << 30}, cols: 640, rows: 480, want: image.Point{X: 640, Y: 480}},
+		{name: "target long edge", opts: Options{TargetLongEdge: 320}, cols: 1080, rows: 1920, want: image.Point{X: 180, Y: 320}},
+		{name: "one pixel long edge", opts: Options{TargetLongEdge: 1}, cols: 1920, rows: 1080, want: image.Point{X: 1, Y: 1}},
+		{name: "target long edge never upscales", opts: Options{TargetLongEdge: 4000}, cols: 640, rows: 480, want: image.Point{X: 640, Y: 480}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaler, err := newFrameScaler(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := scaler.targetSize(tt.cols, tt.rows); got != tt.want {
+				t.Errorf("targetSize(%v, %v)=%v, want %v", tt.cols, tt.rows, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	SegmentationFixed = "fixed"
+	SegmentationScene = "scene"
+
+	DefaultSceneThreshold = 0.4
+
+	// sceneAnalysisWidth is the width frames are shrunk to before their histograms are compared.
+	sceneAnalysisWidth = 160
+)
+
+// fixedJobs splits the video into periodSeconds windows, the last one clipped to the video duration.
+func fixedJobs(durationMs float64, periodSeconds float64) []segmentJob {
+	var jobs []segmentJob
+	for desiredIdx := float64(0); desiredIdx <= durationMs; desiredIdx += (periodSeconds * 1000) {
+		endMs := desiredIdx + periodSeconds*1000
+		if endMs > durationMs {
+			endMs = durationMs
+		}
+		jobs = append(jobs, segmentJob{index: len(jobs), startMs: desiredIdx, endMs: endMs})
+	}
+	return jobs
+}
+
+// sceneDetector finds shot boundaries by comparing hue/saturation histograms of consecutive frames.
+type sceneDetector struct {
+	// Threshold is the Bhattacharyya distance (0 identical, 1 disjoint) above which a cut is detected.
+	Threshold float64
+	// MinSceneMs suppresses cuts that would produce scenes shorter than this.
+	MinSceneMs float64
+}
+
+// detect decodes every frame of the video and returns one job per scene.
+func (d *sceneDetector) detect(ctx context.Context, videoFilePath string, durationMs float64) ([]segmentJob, error) {
+	vc, err := gocv.VideoCaptureFile(videoFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("action=sceneDetector.detect path=%v err=%v", videoFilePath, err)
+	}
+	defer vc.Close()
+
+	frame := gocv.NewMat()
+	defer frame.Close()
+	prevHist := gocv.NewMat()
+	defer prevHist.Close()
+
+	var jobs []segmentJob
+	sceneStartMs := float64(0)
+	hasPrev := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if ok := vc.Read(&frame); !ok {
+			break
+		}
+		if frame.Empty() {
+			continue
+		}
+		posMs := vc.Get(gocv.VideoCapturePosMsec)
+
+		hist := frameHistogram(frame)
+		if hasPrev {
+			distance := float64(gocv.CompareHist(prevHist, hist, gocv.HistCmpBhattacharya))
+			if distance > d.Threshold && posMs-sceneStartMs >= d.MinSceneMs {
+				log.Printf("scene cut at=%vms distance=%v", posMs, distance)
+				jobs = append(jobs, segmentJob{index: len(jobs), startMs: sceneStartMs, endMs: posMs})
+				sceneStartMs = posMs
+			}
+		}
+		prevHist.Close()
+		prevHist = hist
+		hasPrev = true
+	}
+	if sceneStartMs < durationMs || len(jobs) == 0 {
+		jobs = append(jobs, segmentJob{index: len(jobs), startMs: sceneStartMs, endMs: durationMs})
+	}
+	return jobs, nil
+}
+
+// frameHistogram returns the normalized 2D hue/saturation histogram of a shrunk copy of frame.
+func frameHistogram(frame gocv.Mat) gocv.Mat {
+	small := gocv.NewMat()
+	defer small.Close()
+	scale := float64(sceneAnalysisWidth) / float64(frame.Cols())
+	if scale > 1 {
+		scale = 1
+	}
+	gocv.Resize(frame, &small, image.Point{X: 0, Y: 0}, scale, scale, gocv.InterpolationArea)
+
+	hsv := gocv.NewMat()
+	defer hsv.Close()
+	gocv.CvtColor(small, &hsv, gocv.ColorBGRToHSV)
+
+	mask := gocv.NewMat()
+	defer mask.Close()
+	hist := gocv.NewMat()
+	gocv.CalcHist([]gocv.Mat{hsv}, []int{0, 1}, mask, &hist, []int{50, 60}, []float64{0, 180, 0, 256}, false)
+	gocv.Normalize(hist, &hist, 0, 1, gocv.NormMinMax)
+	return hist
+}
+
+func validSegmentation(mode string) error {
+	switch mode {
+	case "", SegmentationFixed, SegmentationScene:
+		return nil
+	default:
+		return fmt.Errorf("action=validSegmentation segmentation=%v err=unknown segmentation, available: %v", mode, strings.Join([]string{SegmentationFixed, SegmentationScene}, ", "))
+	}
+}
@@ -0,0 +1,163 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+)
+
+const (
+	FormatCSV           = "csv"
+	FormatJSON          = "json"
+	FormatJSONL         = "jsonl"
+	FormatParquet       = "parquet"
+	FormatParquetNested = "parquet-nested"
+)
+
+var formats = []string{FormatCSV, FormatJSON, FormatJSONL, FormatParquet, FormatParquetNested}
+
+// Sink receives segments in SampleNumber order as they are extracted.
+type Sink interface {
+	Write(segment *Segment) error
+	// Close flushes buffered output. It does not close the underlying writer.
+	Close() error
+}
+
+// ValidateFormat fails for unknown output formats. An empty format means csv.
+func ValidateFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, known := range formats {
+		if strings.EqualFold(format, known) {
+			return nil
+		}
+	}
+	return fmt.Errorf("action=validateFormat format=%v err=unknown format, available: %v", format, strings.Join(formats, ", "))
+}
+
+// AppendableFormat reports whether results of format can be appended to an existing file.
+func AppendableFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case "", FormatCSV, FormatJSONL:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewSink returns the sink writing format to w.
+func NewSink(format string, w io.Writer) (Sink, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return NewJSONSink(w), nil
+	case FormatJSONL:
+		return NewJSONLinesSink(w), nil
+	case FormatParquet:
+		return NewParquetSink(w, false)
+	case FormatParquetNested:
+		return NewParquetSink(w, true)
+	default:
+		return NewCSVSink(w), nil
+	}
+}
+
+// NewAppendingSink is NewSink for a writer that already holds results of format, so the csv header is not repeated.
+func NewAppendingSink(format string, w io.Writer) (Sink, error) {
+	sink, err := NewSink(format, w)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := sink.(*csvSink); ok {
+		c.headerWritten = true
+	}
+	return sink, nil
+}
+
+type csvSink struct {
+	w             io.Writer
+	headerWritten bool
+}
+
+// NewCSVSink writes one csv row per palette color. The header is written before the first segment.
+func NewCSVSink(w io.Writer) Sink {
+	return &csvSink{w: w}
+}
+
+func (c *csvSink) Write(segment *Segment) error {
+	results := segment.Results()
+	if !c.headerWritten {
+		err := gocsv.Marshal(results, c.w)
+		if err != nil {
+			return fmt.Errorf("action=csvSink.write segment=%v err=%v", segment.Number, err)
+		}
+		c.headerWritten = true
+		return nil
+	}
+	err := gocsv.MarshalWithoutHeaders(results, c.w)
+	if err != nil {
+		return fmt.Errorf("action=csvSink.writeWithoutHeaders segment=%v err=%v", segment.Number, err)
+	}
+	return nil
+}
+
+func (c *csvSink) Close() error {
+	return nil
+}
+
+type jsonSink struct {
+	w   io.Writer
+	doc ResultDocument
+}
+
+// NewJSONSink buffers every segment and writes a single ResultDocument on Close.
+func NewJSONSink(w io.Writer) Sink {
+	return &jsonSink{w: w, doc: ResultDocument{Segments: []*SegmentRecord{}}}
+}
+
+func (j *jsonSink) Write(segment *Segment) error {
+	if j.doc.Video == nil {
+		j.doc.Video = segment.Video.Record()
+	}
+	j.doc.Segments = append(j.doc.Segments, segment.Record())
+	return nil
+}
+
+func (j *jsonSink) Close() error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(j.doc)
+	if err != nil {
+		return fmt.Errorf("action=jsonSink.close err=%v", err)
+	}
+	return nil
+}
+
+type jsonLinesSink struct {
+	enc *json.Encoder
+}
+
+// NewJSONLinesSink writes one self-contained SegmentRecord, including its video, per line.
+func NewJSONLinesSink(w io.Writer) Sink {
+	return &jsonLinesSink{enc: json.NewEncoder(w)}
+}
+
+func (j *jsonLinesSink) Write(segment *Segment) error {
+	record := segment.Record()
+	record.Video = segment.Video.Record()
+	err := j.enc.Encode(record)
+	if err != nil {
+		return fmt.Errorf("action=jsonLinesSink.write segment=%v err=%v", segment.Number, err)
+	}
+	return nil
+}
+
+func (j *jsonLinesSink) Close() error {
+	return nil
+}
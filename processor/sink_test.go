@@ -0,0 +1,161 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// sinkSegments returns two segments of one video, the second with two colors.
+func sinkSegments() []*Segment {
+	video := &Video{Serial: "a", URL: "/videos/a.mp4", DurationSeconds: 20, FPS: 25}
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	return []*Segment{
+		{Video: video, ID: "a-1", Number: 1, End: 10 * time.Second, FrameTime: 40 * time.Millisecond, Duration: 10,
+			Colors: []PaletteColor{{Color: red, Weight: 1}}},
+		{Video: video, ID: "a-2", Number: 2, Start: 10 * time.Second, End: 20 * time.Second, FrameTime: 10 * time.Second, Duration: 10,
+			Colors: []PaletteColor{{Color: red, Weight: 0.75}, {Color: blue, Weight: 0.25}}},
+	}
+}
+
+func writeSegments(t *testing.T, sink Sink, segments []*Segment) {
+	t.Helper()
+	for _, segment := range segments {
+		if err := sink.Write(segment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format     string
+		valid      bool
+		appendable bool
+	}{
+		{format: "", valid: true, appendable: true},
+		{format: "CSV", valid: true, appendable: true},
+		{format: "json", valid: true},
+		{format: "jsonl", valid: true, appendable: true},
+		{format: "parquet", valid: true},
+		{format: "parquet-nested", valid: true},
+		{format: "xml"},
+	}
+	for _, tt := range tests {
+		if err := ValidateFormat(tt.format); (err == nil) != tt.valid {
+			t.Errorf("ValidateFormat(%q)=%v, want valid %v", tt.format, err, tt.valid)
+		}
+		if _, err := NewSink(tt.format, &bytes.Buffer{}); (err == nil) != tt.valid {
+			t.Errorf("NewSink(%q)=%v, want valid %v", tt.format, err, tt.valid)
+		}
+		if got := AppendableFormat(tt.format); got != tt.appendable {
+			t.Errorf("AppendableFormat(%q)=%v, want %v", tt.format, got, tt.appendable)
+		}
+	}
+}
+
+func TestCSVSink(t *testing.T) {
+	var out bytes.Buffer
+	writeSegments(t, NewCSVSink(&out), sinkSegments())
+	// appending repeats no header
+	sink, err := NewAppendingSink(FormatCSV, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSegments(t, sink, sinkSegments()[:1])
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("rows=%v, want a header and 4 colors", len(rows))
+	}
+	column := map[string]int{}
+	for i, name := range rows[0] {
+		column[name] = i
+	}
+	tests := []struct {
+		sampleNumber string
+		hex          string
+		frameMs      string
+	}{
+		{sampleNumber: "1", hex: "#ff0000", frameMs: "40"},
+		{sampleNumber: "2", hex: "#ff0000", frameMs: "10000"},
+		{sampleNumber: "2", hex: "#0000ff", frameMs: "10000"},
+		{sampleNumber: "1", hex: "#ff0000", frameMs: "40"},
+	}
+	for i, tt := range tests {
+		row := rows[i+1]
+		if row[column["sample_number"]] != tt.sampleNumber || row[column["hex"]] != tt.hex || row[column["frame_ms"]] != tt.frameMs {
+			t.Errorf("row %v sample_number=%v hex=%v frame_ms=%v, want %+v", i+1,
+				row[column["sample_number"]], row[column["hex"]], row[column["frame_ms"]], tt)
+		}
+		if row[column["source_serial"]] != "a" {
+			t.Errorf("row %v source_serial=%v, want a", i+1, row[column["source_serial"]])
+		}
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	var out bytes.Buffer
+	writeSegments(t, NewJSONSink(&out), sinkSegments())
+
+	var doc ResultDocument
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Video == nil || doc.Video.Serial != "a" || doc.Video.FPS != 25 {
+		t.Errorf("video=%+v, want serial a at 25 fps", doc.Video)
+	}
+	if len(doc.Segments) != 2 {
+		t.Fatalf("segments=%v, want 2", len(doc.Segments))
+	}
+	second := doc.Segments[1]
+	if second.Video != nil {
+		t.Errorf("segment video=%+v, want it only on the document", second.Video)
+	}
+	if second.Number != 2 || second.StartMs != 10000 || second.EndMs != 20000 || len(second.Colors) != 2 || second.Colors[1].Hex != "#0000ff" {
+		t.Errorf("second segment=%+v", second)
+	}
+
+	// without segments the document still has an empty list
+	out.Reset()
+	writeSegments(t, NewJSONSink(&out), nil)
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil || doc.Segments == nil || len(doc.Segments) != 0 {
+		t.Errorf("empty document=%v, err=%v, want no segments", out.String(), err)
+	}
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	var out bytes.Buffer
+	writeSegments(t, NewJSONLinesSink(&out), sinkSegments())
+	sink, err := NewAppendingSink(FormatJSONL, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSegments(t, sink, sinkSegments()[:1])
+
+	var numbers []int
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var record SegmentRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		if record.Video == nil || record.Video.Serial != "a" {
+			t.Errorf("line %q has no video", scanner.Text())
+		}
+		numbers = append(numbers, record.Number)
+	}
+	if len(numbers) != 3 || numbers[0] != 1 || numbers[1] != 2 || numbers[2] != 1 {
+		t.Errorf("numbers=%v, want [1 2 1]", numbers)
+	}
+}
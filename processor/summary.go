@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorname"
+	"github.com/satori/go.uuid"
+)
+
+// SummaryNumber is the SampleNumber of the whole-video summary palette.
+const SummaryNumber = 0
+
+// summarySamplesPerSecond is how many weighted samples a segment contributes per second it covers.
+const summarySamplesPerSecond = 100
+
+// SummaryBuilder clusters segment palettes, weighted by pixel share and segment duration,
+// into a single palette for the whole video.
+type SummaryBuilder struct {
+	extractor   PaletteExtractor
+	paletteSize int
+	names       *colorname.Dictionary
+	brand       *brandChecker
+	video       *Video
+	samples     [][3]int
+}
+
+// NewSummaryBuilder uses the same extractor, color space, palette size, color names and brand palette as the segments.
+func NewSummaryBuilder(opts Options) (*SummaryBuilder, error) {
+	extractor, err := newPaletteExtractor(opts)
+	if err != nil {
+		return nil, fmt.Errorf("action=newSummaryBuilder err=%v", err)
+	}
+	if opts.PaletteSize < 1 {
+		return nil, fmt.Errorf("action=newSummaryBuilder palette_size=%v err=palette size should be greater than 0", opts.PaletteSize)
+	}
+	names, err := opts.colorNames()
+	if err != nil {
+		return nil, fmt.Errorf("action=newSummaryBuilder err=%v", err)
+	}
+	brand, err := newBrandChecker(opts)
+	if err != nil {
+		return nil, fmt.Errorf("action=newSummaryBuilder err=%v", err)
+	}
+	return &SummaryBuilder{
+		extractor:   extractor,
+		paletteSize: opts.PaletteSize,
+		names:       names,
+		brand:       brand,
+	}, nil
+}
+
+// Add accounts for one segment palette.
+func (b *SummaryBuilder) Add(segment *Segment) {
+	b.video = segment.Video
+	duration := segment.Duration
+	if duration <= 0 {
+		duration = 1
+	}
+	for _, clr := range segment.Colors {
+		n := int(math.Round(clr.Weight * duration * summarySamplesPerSecond))
+		px := toPixel(clr.Color)
+		for i := 0; i < n; i++ {
+			b.samples = append(b.samples, px)
+		}
+	}
+}
+
+// Summary returns the summary palette as a segment numbered SummaryNumber that spans the whole video.
+// It returns nil without error when no segment was added, like for a video without decodable frames.
+func (b *SummaryBuilder) Summary() (*Segment, error) {
+	if b.video == nil || len(b.samples) == 0 {
+		return nil, nil
+	}
+	samples := capPixels(b.samples, maxPooledPixels)
+	palette, weights, err := extractPalette(b.extractor, samples, b.paletteSize)
+	if err != nil {
+		return nil, fmt.Errorf("action=summaryBuilder.summary err=%v", err)
+	}
+
+	end := time.Duration(b.video.DurationSeconds * float64(time.Second))
+	summary := &Segment{
+		Video:     b.video,
+		ID:        uuid.NewV4().String(),
+		Number:    SummaryNumber,
+		End:       end,
+		FrameTime: NoFrameMs * time.Millisecond,
+		Duration:  b.video.DurationSeconds,
+		PaletteID: uuid.NewV4().String(),
+	}
+	summary.Colors = newPaletteColors(palette, weights, b.names)
+	b.brand.check(summary)
+	return summary, nil
+}
+
+// Summarize builds the whole-video summary palette from already extracted segments, nil when there are none.
+func Summarize(segments []*Segment, opts Options) (*Segment, error) {
+	builder, err := NewSummaryBuilder(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, segment := range segments {
+		builder.Add(segment)
+	}
+	return builder.Summary()
+}
+
+func toPixel(c color.Color) [3]int {
+	r, g, b, _ := c.RGBA()
+	return [3]int{int(r >> 8), int(g >> 8), int(b >> 8)}
+}
@@ -0,0 +1,126 @@
+package processor
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+const (
+	DefaultTimelineWidth  = 1920
+	DefaultTimelineHeight = 270
+)
+
+// timelineRect is one palette color on the timeline, its bounds given as fractions of the image size.
+type timelineRect struct {
+	segment        *Segment
+	clr            PaletteColor
+	x0, x1, y0, y1 float64
+}
+
+// timelineLayout places segments left to right as stripes as wide as the time they cover,
+// each stripe stacking its colors top to bottom by decreasing weight with heights proportional to weight.
+func timelineLayout(segments []*Segment) []timelineRect {
+	var total time.Duration
+	for _, s := range segments {
+		if s.End > total {
+			total = s.End
+		}
+	}
+	var rects []timelineRect
+	for i, s := range segments {
+		// segments without time bounds share the width equally
+		x0, x1 := float64(i)/float64(len(segments)), float64(i+1)/float64(len(segments))
+		if total > 0 {
+			x0, x1 = float64(s.Start)/float64(total), float64(s.End)/float64(total)
+		}
+
+		colors := append([]PaletteColor(nil), s.Colors...)
+		sort.SliceStable(colors, func(a, b int) bool { return colors[a].Weight > colors[b].Weight })
+		var weightSum float64
+		for _, clr := range colors {
+			weightSum += clr.Weight
+		}
+		var y float64
+		for _, clr := range colors {
+			share := 1 / float64(len(colors))
+			if weightSum > 0 {
+				share = clr.Weight / weightSum
+			}
+			rects = append(rects, timelineRect{segment: s, clr: clr, x0: x0, x1: x1, y0: y, y1: y + share})
+			y += share
+		}
+	}
+	return rects
+}
+
+// RenderTimeline draws the "movie barcode" of segments: the whole video in one image of width x height.
+func RenderTimeline(segments []*Segment, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for _, r := range timelineLayout(segments) {
+		bounds := image.Rect(
+			int(math.Round(r.x0*float64(width))),
+			int(math.Round(r.y0*float64(height))),
+			int(math.Round(r.x1*float64(width))),
+			int(math.Round(r.y1*float64(height))),
+		)
+		draw.Draw(img, bounds, &image.Uniform{r.clr.Color}, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// WriteTimelineSVG writes the timeline as an svg of width x height. Every color rect carries a title
+// with its segment number, time range, hex and weight, shown as a tooltip by browsers.
+func WriteTimelineSVG(w io.Writer, segments []*Segment, width, height int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n", width, height, width, height)
+	for _, r := range timelineLayout(segments) {
+		hex := colorspace.Hex(r.clr.Color)
+		fmt.Fprintf(bw, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" fill="%s"><title>segment %d %s-%s %s %.1f%%</title></rect>`+"\n",
+			r.x0*float64(width), r.y0*float64(height), (r.x1-r.x0)*float64(width), (r.y1-r.y0)*float64(height), hex,
+			r.segment.Number, r.segment.Start, r.segment.End, hex, r.clr.Weight*100)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("action=writeTimelineSVG err=%v", err)
+	}
+	return nil
+}
+
+// writeTimeline writes timeline.png and/or timeline.svg to the visualize output folder.
+func writeTimeline(args *VisualizeArgs, segments []*Segment) error {
+	width, height := args.TimelineWidth, args.TimelineHeight
+	if width <= 0 {
+		width = DefaultTimelineWidth
+	}
+	if height <= 0 {
+		height = DefaultTimelineHeight
+	}
+	if args.Timeline {
+		out := filepath.Join(args.OutputFolder, "timeline.png")
+		if err := writePNG(out, RenderTimeline(segments, width, height)); err != nil {
+			return fmt.Errorf("action=writeTimeline out=%v err=%v", out, err)
+		}
+	}
+	if args.TimelineSVG {
+		out := filepath.Join(args.OutputFolder, "timeline.svg")
+		f, err := os.Create(out)
+		if err != nil {
+			return fmt.Errorf("action=writeTimeline out=%v err=%v", out, err)
+		}
+		defer f.Close()
+		if err := WriteTimelineSVG(f, segments, width, height); err != nil {
+			return fmt.Errorf("action=writeTimeline out=%v err=%v", out, err)
+		}
+	}
+	return nil
+}
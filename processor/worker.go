@@ -0,0 +1,241 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/kennykarnama/color-thief/helper"
+	"github.com/kennykarnama/video-color-palette-generator/colorname"
+	"gocv.io/x/gocv"
+)
+
+// segmentJob asks a worker to sample the segment spanning [startMs, endMs).
+type segmentJob struct {
+	index   int
+	startMs float64
+	endMs   float64
+}
+
+// segmentSample is what a worker produced for one job. exists is false when no frame could be decoded.
+type segmentSample struct {
+	job     segmentJob
+	exists  bool
+	frameMs float64
+	frame   image.Image
+	colors  []PaletteColor
+	elapsed time.Duration
+	err     error
+}
+
+// AllFramesPerSegment makes every decoded frame of a segment contribute to its palette.
+const AllFramesPerSegment = -1
+
+// maxPooledPixels bounds the pixels clustered per segment when several frames are pooled.
+const maxPooledPixels = 1 << 18
+
+type segmentSampler struct {
+	videoFilePath    string
+	extractor        PaletteExtractor
+	paletteSize      int
+	framesPerSegment int
+	scaler           *frameScaler
+	names            *colorname.Dictionary
+	keepFrame        bool
+}
+
+// run decodes and clusters jobs with its own VideoCapture until jobs is closed or ctx is done.
+func (s *segmentSampler) run(ctx context.Context, jobs <-chan segmentJob, samples chan<- *segmentSample) {
+	vc, err := gocv.VideoCaptureFile(s.videoFilePath)
+	if err != nil {
+		sendSample(ctx, samples, &segmentSample{err: fmt.Errorf("action=run.video_capture_file path=%v err=%v", s.videoFilePath, err)})
+		return
+	}
+	defer vc.Close()
+
+	videoFrame := gocv.NewMat()
+	defer videoFrame.Close()
+
+	for job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		sample, err := s.sample(ctx, vc, &videoFrame, job)
+		if err != nil {
+			sample = &segmentSample{job: job, err: err}
+		}
+		if !sendSample(ctx, samples, sample) {
+			return
+		}
+	}
+}
+
+func (s *segmentSampler) sample(ctx context.Context, vc *gocv.VideoCapture, videoFrame *gocv.Mat, job segmentJob) (*segmentSample, error) {
+	start := time.Now()
+
+	sample := &segmentSample{job: job}
+
+	var pixels [][3]int
+	addFrame := func() error {
+		if !sample.exists {
+			// position of the frame that was just decoded, which can differ from the seek target
+			sample.frameMs = vc.Get(gocv.VideoCapturePosMsec)
+		}
+		sample.exists = true
+
+		// scale frame
+		scaledVideoFrame := gocv.NewMat()
+		s.scaler.resize(*videoFrame, &scaledVideoFrame)
+
+		tmpImage, err := scaledVideoFrame.ToImage()
+		scaledVideoFrame.Close()
+		if err != nil {
+			return fmt.Errorf("action=run.scaledVideoFrameToImage err=%v", err)
+		}
+		pixels = append(pixels, helper.SubsamplingPixelsFromImage(tmpImage)...)
+
+		if s.keepFrame && sample.frame == nil {
+			// the Mat is reused for the next frame, so keep a Go copy of the segment's first frame
+			sample.frame, err = videoFrame.ToImage()
+			if err != nil {
+				return fmt.Errorf("action=run.videoFrameToImage err=%v", err)
+			}
+		}
+		return nil
+	}
+
+	if s.framesPerSegment == AllFramesPerSegment {
+		vc.Set(gocv.VideoCapturePosMsec, job.startMs)
+		for {
+			ok, err := readFrame(ctx, vc, videoFrame)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			// always keep the first frame so that zero-length segments still get a palette
+			if sample.exists && vc.Get(gocv.VideoCapturePosMsec) >= job.endMs {
+				break
+			}
+			if err := addFrame(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for _, posMs := range frameTimestamps(job, s.framesPerSegment) {
+			vc.Set(gocv.VideoCapturePosMsec, posMs)
+			ok, err := readFrame(ctx, vc, videoFrame)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			if err := addFrame(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !sample.exists {
+		return sample, nil
+	}
+
+	pixels = capPixels(pixels, maxPooledPixels)
+	palette, weights, err := extractPalette(s.extractor, pixels, s.paletteSize)
+	if err != nil {
+		return nil, fmt.Errorf("action=run.ExtractPalette err=%v", err)
+	}
+	sample.colors = newPaletteColors(palette, weights, s.names)
+	sample.elapsed = time.Since(start)
+	return sample, nil
+}
+
+// readFrame reads the next non-empty frame into videoFrame. It returns false once the video ends.
+func readFrame(ctx context.Context, vc *gocv.VideoCapture, videoFrame *gocv.Mat) (bool, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if ok := vc.Read(videoFrame); !ok {
+			log.Printf("Video frame closed")
+			return false, nil
+		}
+		if videoFrame.Empty() {
+			log.Printf("Video frame empty")
+			continue
+		}
+		return true, nil
+	}
+}
+
+// frameTimestamps spreads n sample positions evenly over the segment, starting at its beginning.
+func frameTimestamps(job segmentJob, n int) []float64 {
+	if n < 1 {
+		n = 1
+	}
+	step := (job.endMs - job.startMs) / float64(n)
+	timestamps := make([]float64, 0, n)
+	for i := 0; i < n; i++ {
+		timestamps = append(timestamps, job.startMs+float64(i)*step)
+	}
+	return timestamps
+}
+
+// capPixels keeps at most max pixels, taken at an even stride so every pooled frame stays represented.
+func capPixels(pixels [][3]int, max int) [][3]int {
+	if len(pixels) <= max {
+		return pixels
+	}
+	stride := float64(len(pixels)) / float64(max)
+	capped := make([][3]int, 0, max)
+	for i := 0; i < max; i++ {
+		capped = append(capped, pixels[int(float64(i)*stride)])
+	}
+	return capped
+}
+
+func sendSample(ctx context.Context, samples chan<- *segmentSample, sample *segmentSample) bool {
+	select {
+	case samples <- sample:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// runSamplers fans jobs out to workers samplers and returns their samples in arbitrary order.
+// The samples channel is closed once every worker has exited.
+func runSamplers(ctx context.Context, sampler *segmentSampler, workers int, jobs []segmentJob) <-chan *segmentSample {
+	jobCh := make(chan segmentJob)
+	samples := make(chan *segmentSample, workers)
+
+	go func() {
+		defer close(jobCh)
+		for _, job := range jobs {
+			select {
+			case jobCh <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sampler.run(ctx, jobCh, samples)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+	return samples
+}
@@ -0,0 +1,212 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	s3cli "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Client is the S3 API used by sources and destinations. An empty uri.Region is left to the client,
+// see SessionClient.
+type Client interface {
+	Download(ctx context.Context, uri *S3URI, target string) error
+	PutObject(ctx context.Context, uri *S3URI, body io.Reader) error
+	GetObject(ctx context.Context, uri *S3URI) (io.ReadCloser, error)
+}
+
+// URLParser is implemented by clients that recognize the URLs of their own endpoint.
+type URLParser interface {
+	ParseURL(s3URL string) (*S3URI, error)
+}
+
+// ParseURLFor parses s3URL with client when it is a URLParser, with ParseURL otherwise.
+func ParseURLFor(client Client, s3URL string) (*S3URI, error) {
+	if parser, ok := client.(URLParser); ok {
+		return parser.ParseURL(s3URL)
+	}
+	return ParseURL(s3URL)
+}
+
+// IsHTTPURL reports whether uri is an http(s) URL the default client reads as S3: one of the
+// amazonaws.com styles, or under the configured endpoint.
+func IsHTTPURL(uri string) bool {
+	lower := strings.ToLower(uri)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return false
+	}
+	_, err := ParseURLFor(DefaultClient(), uri)
+	return err == nil
+}
+
+// SessionClient is the AWS SDK Client. It builds one session per region on first use.
+// On AWS, a URI without region, like https://bucket.s3.amazonaws.com/key, goes to the region
+// of its bucket, looked up once; with a custom Endpoint it goes to the configured Region.
+type SessionClient struct {
+	cfg Config
+	// bucketRegion looks up the region of a bucket, s3manager.GetBucketRegion outside tests.
+	bucketRegion func(ctx context.Context, sess *session.Session, bucket, regionHint string) (string, error)
+
+	mu            sync.Mutex
+	regions       map[string]*regionClient
+	bucketRegions map[string]string
+}
+
+// regionClient holds the clients of one region.
+type regionClient struct {
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+	svc        *s3cli.S3
+	sess       *session.Session
+}
+
+func NewSessionClient(cfg Config) *SessionClient {
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	return &SessionClient{
+		cfg:           cfg,
+		bucketRegion:  getBucketRegion,
+		regions:       map[string]*regionClient{},
+		bucketRegions: map[string]string{},
+	}
+}
+
+func (c *SessionClient) Config() Config {
+	return c.cfg
+}
+
+// ParseURL parses s3URL, recognizing URLs under the client's endpoint.
+func (c *SessionClient) ParseURL(s3URL string) (*S3URI, error) {
+	return ParseURLWithEndpoint(s3URL, c.cfg.Endpoint)
+}
+
+// region returns the clients of region, the configured default region when empty.
+func (c *SessionClient) region(region string) (*regionClient, error) {
+	if region == "" {
+		region = c.cfg.Region
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rc, ok := c.regions[region]; ok {
+		return rc, nil
+	}
+	awsCfg := &aws.Config{
+		Region:           aws.String(region),
+		S3ForcePathStyle: aws.Bool(c.cfg.ForcePathStyle),
+	}
+	if c.cfg.Endpoint != "" {
+		awsCfg.Endpoint = aws.String(c.cfg.Endpoint)
+	}
+	if c.cfg.AccessKeyID != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentials(c.cfg.AccessKeyID, c.cfg.SecretAccessKey, c.cfg.SessionToken)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:  *awsCfg,
+		Profile: c.cfg.Profile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rc := &regionClient{
+		uploader:   s3manager.NewUploader(sess),
+		downloader: s3manager.NewDownloader(sess),
+		svc:        s3cli.New(sess),
+		sess:       sess,
+	}
+	c.regions[region] = rc
+	return rc, nil
+}
+
+func getBucketRegion(ctx context.Context, sess *session.Session, bucket, regionHint string) (string, error) {
+	return s3manager.GetBucketRegion(ctx, sess, bucket, regionHint)
+}
+
+// client returns the clients of the region of uri.
+func (c *SessionClient) client(ctx context.Context, uri *S3URI) (*regionClient, error) {
+	if uri.Region != "" || c.cfg.Endpoint != "" {
+		return c.region(uri.Region)
+	}
+	c.mu.Lock()
+	region, ok := c.bucketRegions[uri.Bucket]
+	c.mu.Unlock()
+	if !ok {
+		rc, err := c.region("")
+		if err != nil {
+			return nil, err
+		}
+		region, err = c.bucketRegion(ctx, rc.sess, uri.Bucket, c.cfg.Region)
+		if err != nil {
+			return nil, fmt.Errorf("bucket=%v err=finding its region: %v, pass the region in the URL "+
+				"(s3://bucket/key?region= or https://bucket.s3.region.amazonaws.com/key)", uri.Bucket, err)
+		}
+		c.mu.Lock()
+		c.bucketRegions[uri.Bucket] = region
+		c.mu.Unlock()
+	}
+	return c.region(region)
+}
+
+func (c *SessionClient) PutObject(ctx context.Context, uri *S3URI, body io.Reader) error {
+	rc, err := c.client(ctx, uri)
+	if err != nil {
+		return err
+	}
+	_, err = rc.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(uri.Bucket),
+		Key:    aws.String(uri.Key),
+		Body:   body,
+	})
+	return err
+}
+
+func (c *SessionClient) GetObject(ctx context.Context, uri *S3URI) (io.ReadCloser, error) {
+	rc, err := c.client(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	result, err := rc.svc.GetObjectWithContext(ctx, &s3cli.GetObjectInput{
+		Bucket: aws.String(uri.Bucket),
+		Key:    aws.String(uri.Key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Body, nil
+}
+
+// Download writes the object to target, whose folder must exist. A failed download leaves no file behind.
+func (c *SessionClient) Download(ctx context.Context, uri *S3URI, target string) error {
+	rc, err := c.client(ctx, uri)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = rc.downloader.DownloadWithContext(
+		ctx,
+		file,
+		&s3cli.GetObjectInput{
+			Bucket: aws.String(uri.Bucket),
+			Key:    aws.String(uri.Key),
+		},
+	)
+	if err != nil {
+		// do not leave a partially downloaded object behind
+		file.Close()
+		os.Remove(target)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+func TestSessionClientRegion(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		uri      S3URI
+		// lookup is the region of the bucket, the lookup fails when empty
+		lookup      string
+		wantRegion  string
+		wantLookups int
+		wantErr     bool
+	}{
+		{name: "region in the uri", uri: S3URI{Region: "eu-west-1", Bucket: "b"}, lookup: "us-west-2", wantRegion: "eu-west-1"},
+		{name: "looked up", uri: S3URI{Bucket: "b"}, lookup: "us-west-2", wantRegion: "us-west-2", wantLookups: 1},
+		{name: "lookup fails", uri: S3URI{Bucket: "b"}, wantLookups: 2, wantErr: true},
+		{name: "custom endpoint", endpoint: "http://localhost:9000", uri: S3URI{Bucket: "b"}, lookup: "us-west-2", wantRegion: "ap-southeast-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSessionClient(Config{Endpoint: tt.endpoint, AccessKeyID: "id", SecretAccessKey: "secret"})
+			var lookups int
+			c.bucketRegion = func(ctx context.Context, sess *session.Session, bucket, regionHint string) (string, error) {
+				lookups++
+				if regionHint != DefaultRegion {
+					t.Errorf("regionHint=%v, want %v", regionHint, DefaultRegion)
+				}
+				if tt.lookup == "" {
+					return "", errors.New("NotFound")
+				}
+				return tt.lookup, nil
+			}
+			// the second request reuses the looked up region
+			for i := 0; i < 2; i++ {
+				rc, err := c.client(context.Background(), &tt.uri)
+				if tt.wantErr {
+					if err == nil || !strings.Contains(err.Error(), "pass the region") {
+						t.Fatalf("err=%v, want one asking for the region", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := *rc.svc.Config.Region; got != tt.wantRegion {
+					t.Errorf("region=%v, want %v", got, tt.wantRegion)
+				}
+			}
+			if lookups != tt.wantLookups {
+				t.Errorf("looked up %v times, want %v", lookups, tt.wantLookups)
+			}
+		})
+	}
+}
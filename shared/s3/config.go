@@ -0,0 +1,91 @@
+package s3
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DefaultRegion is used when neither the URI nor the environment name a region.
+const DefaultRegion = "ap-southeast-1"
+
+// Config selects where S3 requests go and with which credentials. An empty Endpoint means AWS itself.
+type Config struct {
+	// Region is the default for URIs without one.
+	Region string
+	// Endpoint is the base URL of an S3-compatible store such as MinIO or LocalStack, e.g. http://localhost:9000.
+	Endpoint string
+	// ForcePathStyle addresses buckets as Endpoint/bucket/key instead of bucket.host/key.
+	ForcePathStyle bool
+	// Profile picks a profile of the shared config and credentials files, the SDK default when empty.
+	Profile string
+	// AccessKeyID and SecretAccessKey (and SessionToken for temporary ones) replace the default
+	// credential chain when set.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// ConfigFromEnv reads AWS_REGION (or AWS_DEFAULT_REGION), AWS_PROFILE, S3_ENDPOINT and S3_FORCE_PATH_STYLE.
+// Credentials come from the SDK default chain.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Region:   os.Getenv("AWS_REGION"),
+		Endpoint: os.Getenv("S3_ENDPOINT"),
+		Profile:  os.Getenv("AWS_PROFILE"),
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	cfg.ForcePathStyle, _ = strconv.ParseBool(os.Getenv("S3_FORCE_PATH_STYLE"))
+	return cfg
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultConfig *Config
+	defaultClient Client
+)
+
+// SetConfig replaces the configuration read from the environment, and with it the default client.
+func SetConfig(cfg Config) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultConfig = &cfg
+	defaultClient = NewSessionClient(cfg)
+}
+
+// SetDefaultClient replaces the client behind DefaultClient and the package level functions,
+// e.g. with a fake in tests. URLs are still parsed with the current configuration.
+func SetDefaultClient(client Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultClient = client
+}
+
+// DefaultClient returns the client built from the environment on first use, unless replaced.
+func DefaultClient() Client {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultClient == nil {
+		defaultClient = NewSessionClient(currentConfigLocked())
+	}
+	return defaultClient
+}
+
+func currentConfig() Config {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return currentConfigLocked()
+}
+
+func currentConfigLocked() Config {
+	if defaultConfig == nil {
+		cfg := ConfigFromEnv()
+		defaultConfig = &cfg
+	}
+	return *defaultConfig
+}
@@ -10,6 +10,9 @@ import (
 
 const GlobalDefaultRegion = "us-east-1"
 
+// S3Scheme is the scheme of s3://bucket/key URIs.
+const S3Scheme = "s3"
+
 // taken from https://docs.aws.amazon.com/AmazonS3/latest/userguide/VirtualHosting.html
 var (
 	s3VirtualHostPattern = regexp.MustCompile(`^https://([^\.]+)\.s3\.([^\.]+)\.amazonaws\.com(/[^?^#]*)?`) // https://bucket-name.s3.Region.amazonaws.com
@@ -41,9 +44,23 @@ func KeyEscape(key string) string {
 	return strings.Join(results, "/")
 }
 
-// ParseURL parses an HTTP S3 URL into S3 URI: region, bucket, and key.
-// It handles multiple S3 URL patterns, including the legacy one.
+// ParseURL parses an S3 URL into S3 URI: region, bucket, and key.
+// It handles s3://bucket/key URIs, multiple HTTP S3 URL patterns including the legacy one,
+// and URLs under the configured S3-compatible endpoint.
 func ParseURL(s3URL string) (*S3URI, error) {
+	return ParseURLWithEndpoint(s3URL, currentConfig().Endpoint)
+}
+
+// ParseURLWithEndpoint is ParseURL recognizing the URLs under endpoint, none when empty.
+func ParseURLWithEndpoint(s3URL, endpoint string) (*S3URI, error) {
+	if strings.HasPrefix(s3URL, S3Scheme+"://") {
+		return parseS3Scheme(s3URL)
+	}
+	if endpoint != "" {
+		if uri, err := parseEndpointURL(s3URL, endpoint); err == nil {
+			return uri, nil
+		}
+	}
 	if match := s3VirtualHostPattern.FindStringSubmatch(s3URL); len(match) >= 4 {
 		key, err := url.QueryUnescape(strings.TrimPrefix(match[3], "/"))
 		if err != nil {
@@ -67,12 +84,60 @@ func ParseURL(s3URL string) (*S3URI, error) {
 		if err != nil {
 			return nil, ErrKeyUnescape
 		}
-		// the region will be GlobalDefaultRegion
+		// no region in the host, SessionClient looks up the one of the bucket
 		return &S3URI{Region: "", Bucket: match[1], Key: key}, nil
 	}
 	return nil, ErrURLPatternNotFound
 }
 
+// parseS3Scheme parses s3://bucket/key, the region optionally given as ?region=.
+// Percent escapes in the key are decoded, so a key containing ? or # has to escape them.
+func parseS3Scheme(s3URL string) (*S3URI, error) {
+	u, err := url.Parse(s3URL)
+	if err != nil {
+		return nil, ErrURLPatternNotFound
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, ErrURLPatternNotFound
+	}
+	return &S3URI{Region: u.Query().Get("region"), Bucket: u.Host, Key: key}, nil
+}
+
+// parseEndpointURL parses endpoint/bucket/key (path style) or bucket.endpoint-host/key (virtual host style).
+func parseEndpointURL(s3URL, endpoint string) (*S3URI, error) {
+	u, err := url.Parse(s3URL)
+	if err != nil {
+		return nil, ErrURLPatternNotFound
+	}
+	e, err := url.Parse(endpoint)
+	if err != nil || e.Host == "" || !strings.EqualFold(u.Scheme, e.Scheme) {
+		return nil, ErrURLPatternNotFound
+	}
+	path := strings.TrimPrefix(u.EscapedPath(), "/")
+	var bucket, escapedKey string
+	switch {
+	case strings.EqualFold(u.Host, e.Host):
+		parts := strings.SplitN(path, "/", 2)
+		if len(parts) < 2 {
+			return nil, ErrURLPatternNotFound
+		}
+		bucket, escapedKey = parts[0], parts[1]
+	case strings.HasSuffix(strings.ToLower(u.Host), "."+strings.ToLower(e.Host)):
+		bucket, escapedKey = u.Host[:len(u.Host)-len(e.Host)-1], path
+	default:
+		return nil, ErrURLPatternNotFound
+	}
+	if bucket == "" || escapedKey == "" {
+		return nil, ErrURLPatternNotFound
+	}
+	key, err := url.QueryUnescape(escapedKey)
+	if err != nil {
+		return nil, ErrKeyUnescape
+	}
+	return &S3URI{Region: "", Bucket: bucket, Key: key}, nil
+}
+
 // ReplaceURLIfBucketMatch replaces an S3 URL with a CDN URL if the bucket match
 // In the case of s3URL is not a valid S3 URL or the bucket does not match,
 // the URL is returned unchanged, similar behavior like strings utility from Golang
@@ -0,0 +1,59 @@
+package s3
+
+import "testing"
+
+func TestParseURLWithEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		endpoint string
+		want     S3URI
+		wantErr  error
+	}{
+		{name: "s3 scheme", url: "s3://bucket/videos/a.mp4", want: S3URI{Bucket: "bucket", Key: "videos/a.mp4"}},
+		{name: "s3 scheme with region", url: "s3://bucket/a.mp4?region=eu-west-1", want: S3URI{Region: "eu-west-1", Bucket: "bucket", Key: "a.mp4"}},
+		{name: "s3 scheme escaped key", url: "s3://bucket/a%3Fb.mp4", want: S3URI{Bucket: "bucket", Key: "a?b.mp4"}},
+		{name: "s3 scheme without key", url: "s3://bucket/", wantErr: ErrURLPatternNotFound},
+		{name: "s3 scheme without bucket", url: "s3:///a.mp4", wantErr: ErrURLPatternNotFound},
+		{name: "virtual host", url: "https://bucket.s3.eu-west-1.amazonaws.com/videos/a+b.mp4", want: S3URI{Region: "eu-west-1", Bucket: "bucket", Key: "videos/a b.mp4"}},
+		{name: "virtual host with query", url: "https://bucket.s3.eu-west-1.amazonaws.com/a.mp4?versionId=1", want: S3URI{Region: "eu-west-1", Bucket: "bucket", Key: "a.mp4"}},
+		{name: "path", url: "https://s3.ap-southeast-1.amazonaws.com/bucket/a%2Bb.mp4", want: S3URI{Region: "ap-southeast-1", Bucket: "bucket", Key: "a+b.mp4"}},
+		{name: "dash region", url: "https://bucket.s3-us-west-2.amazonaws.com/a.mp4", want: S3URI{Region: "us-west-2", Bucket: "bucket", Key: "a.mp4"}},
+		{name: "global", url: "https://bucket.s3.amazonaws.com/a.mp4", want: S3URI{Bucket: "bucket", Key: "a.mp4"}},
+		{name: "bad escape", url: "https://bucket.s3.eu-west-1.amazonaws.com/a%zz.mp4", wantErr: ErrKeyUnescape},
+		{name: "other host", url: "https://cdn.example.com/a.mp4", wantErr: ErrURLPatternNotFound},
+		{name: "endpoint path style", url: "http://localhost:9000/bucket/videos/a.mp4", endpoint: "http://localhost:9000", want: S3URI{Bucket: "bucket", Key: "videos/a.mp4"}},
+		{name: "endpoint virtual host", url: "http://bucket.localhost:9000/a.mp4", endpoint: "http://localhost:9000", want: S3URI{Bucket: "bucket", Key: "a.mp4"}},
+		{name: "endpoint without key", url: "http://localhost:9000/bucket", endpoint: "http://localhost:9000", wantErr: ErrURLPatternNotFound},
+		{name: "endpoint other scheme", url: "https://localhost:9000/bucket/a.mp4", endpoint: "http://localhost:9000", wantErr: ErrURLPatternNotFound},
+		{name: "endpoint not configured", url: "http://localhost:9000/bucket/a.mp4", wantErr: ErrURLPatternNotFound},
+		{name: "aws url with endpoint", url: "https://bucket.s3.eu-west-1.amazonaws.com/a.mp4", endpoint: "http://localhost:9000", want: S3URI{Region: "eu-west-1", Bucket: "bucket", Key: "a.mp4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseURLWithEndpoint(tt.url, tt.endpoint)
+			if err != tt.wantErr {
+				t.Fatalf("ParseURLWithEndpoint(%v) err=%v, want %v", tt.url, err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("ParseURLWithEndpoint(%v)=%+v, want %+v", tt.url, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyEscape(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "videos/a.mp4", want: "videos/a.mp4"},
+		{key: "videos/a b+c.mp4", want: "videos/a+b%2Bc.mp4"},
+		{key: "a?b#c", want: "a%3Fb%23c"},
+	}
+	for _, tt := range tests {
+		if got := KeyEscape(tt.key); got != tt.want {
+			t.Errorf("KeyEscape(%q)=%q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package s3test provides an in-memory s3.Client for tests.
+package s3test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	sharedS3Internal "github.com/kennykarnama/video-color-palette-generator/shared/s3"
+)
+
+// Client keeps objects in memory, keyed by bucket and key; regions are recorded but not separated.
+type Client struct {
+	// Err, when set, fails every request.
+	Err error
+
+	mu       sync.Mutex
+	objects  map[string][]byte
+	requests []sharedS3Internal.S3URI
+}
+
+func NewClient() *Client {
+	return &Client{objects: map[string][]byte{}}
+}
+
+func objectKey(bucket, key string) string {
+	return bucket + "/" + key
+}
+
+// Put stores an object as if it had been uploaded.
+func (c *Client) Put(bucket, key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[objectKey(bucket, key)] = append([]byte(nil), data...)
+}
+
+// Object returns a stored object.
+func (c *Client) Object(bucket, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.objects[objectKey(bucket, key)]
+	return data, ok
+}
+
+// Requests returns the URIs of all requests so far, in order.
+func (c *Client) Requests() []sharedS3Internal.S3URI {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]sharedS3Internal.S3URI(nil), c.requests...)
+}
+
+// get records the request and returns the object of uri.
+func (c *Client) get(ctx context.Context, uri *sharedS3Internal.S3URI) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, *uri)
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, ok := c.objects[objectKey(uri.Bucket, uri.Key)]
+	if !ok {
+		return nil, fmt.Errorf("NoSuchKey: bucket=%v key=%v", uri.Bucket, uri.Key)
+	}
+	return data, nil
+}
+
+func (c *Client) Download(ctx context.Context, uri *sharedS3Internal.S3URI, target string) error {
+	data, err := c.get(ctx, uri)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+func (c *Client) GetObject(ctx context.Context, uri *sharedS3Internal.S3URI) (io.ReadCloser, error) {
+	data, err := c.get(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (c *Client) PutObject(ctx context.Context, uri *sharedS3Internal.S3URI, body io.Reader) error {
+	c.mu.Lock()
+	c.requests = append(c.requests, *uri)
+	err := c.Err
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	c.Put(uri.Bucket, uri.Key, data)
+	return nil
+}
@@ -0,0 +1,134 @@
+package similarity
+
+import (
+	"math"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+// flowEpsilon is the smallest amount of flow still worth moving.
+const flowEpsilon = 1e-12
+
+// Swatch is one palette color in CIELAB with its share of the palette.
+type Swatch struct {
+	Lab    colorspace.Lab
+	Weight float64
+}
+
+// Palette is a weighted set of colors. Weights need not be normalized.
+type Palette []Swatch
+
+// normalized returns the palette with weights summing to 1. Palettes without any weight,
+// such as results written before weights existed, count every color equally.
+func (p Palette) normalized() Palette {
+	var total float64
+	for _, s := range p {
+		if s.Weight > 0 {
+			total += s.Weight
+		}
+	}
+	out := make(Palette, len(p))
+	for i, s := range p {
+		out[i] = s
+		switch {
+		case total == 0:
+			out[i].Weight = 1 / float64(len(p))
+		case s.Weight > 0:
+			out[i].Weight = s.Weight / total
+		default:
+			out[i].Weight = 0
+		}
+	}
+	return out
+}
+
+// EMD is the Earth Mover's Distance between two palettes: the least total weight times CIELAB distance
+// needed to turn one palette into the other. Identical palettes are 0 apart; the result is in Lab units.
+// It returns NaN when either palette is empty.
+func EMD(a, b Palette) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return math.NaN()
+	}
+	a, b = a.normalized(), b.normalized()
+	cost := make([][]float64, len(a))
+	for i := range a {
+		cost[i] = make([]float64, len(b))
+		for j := range b {
+			cost[i][j] = labDistance(a[i].Lab, b[j].Lab)
+		}
+	}
+	return transport(a, b, cost)
+}
+
+func labDistance(x, y colorspace.Lab) float64 {
+	dl, da, db := x.L-y.L, x.A-y.A, x.B-y.B
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+type flowEdge struct {
+	to       int
+	capacity float64
+	cost     float64
+	// reverse is the index of the paired residual edge in graph[to].
+	reverse int
+}
+
+// transport solves the transportation problem from supplies a to demands b as a min-cost flow,
+// augmenting along the cheapest residual path until every supply is shipped.
+func transport(a, b Palette, cost [][]float64) float64 {
+	source, sink := len(a)+len(b), len(a)+len(b)+1
+	graph := make([][]flowEdge, len(a)+len(b)+2)
+	addEdge := func(from, to int, capacity, cost float64) {
+		graph[from] = append(graph[from], flowEdge{to: to, capacity: capacity, cost: cost, reverse: len(graph[to])})
+		graph[to] = append(graph[to], flowEdge{to: from, cost: -cost, reverse: len(graph[from]) - 1})
+	}
+	for i := range a {
+		addEdge(source, i, a[i].Weight, 0)
+		for j := range b {
+			addEdge(i, len(a)+j, math.Inf(1), cost[i][j])
+		}
+	}
+	for j := range b {
+		addEdge(len(a)+j, sink, b[j].Weight, 0)
+	}
+
+	var total float64
+	for {
+		// Bellman-Ford, since residual edges carry negative costs
+		dist := make([]float64, len(graph))
+		prevNode := make([]int, len(graph))
+		prevEdge := make([]int, len(graph))
+		for i := range dist {
+			dist[i] = math.Inf(1)
+		}
+		dist[source] = 0
+		for updated, round := true, 0; updated && round < len(graph); round++ {
+			updated = false
+			for u := range graph {
+				if math.IsInf(dist[u], 1) {
+					continue
+				}
+				for k, e := range graph[u] {
+					if e.capacity > flowEpsilon && dist[u]+e.cost < dist[e.to]-flowEpsilon {
+						dist[e.to] = dist[u] + e.cost
+						prevNode[e.to], prevEdge[e.to] = u, k
+						updated = true
+					}
+				}
+			}
+		}
+		if math.IsInf(dist[sink], 1) {
+			return total
+		}
+		flow := math.Inf(1)
+		for v := sink; v != source; v = prevNode[v] {
+			flow = math.Min(flow, graph[prevNode[v]][prevEdge[v]].capacity)
+		}
+		for v := sink; v != source; v = prevNode[v] {
+			e := &graph[prevNode[v]][prevEdge[v]]
+			e.capacity -= flow
+			graph[v][e.reverse].capacity += flow
+		}
+		total += flow * dist[sink]
+	}
+}
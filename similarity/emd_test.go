@@ -0,0 +1,50 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+)
+
+func gray(l, weight float64) Swatch {
+	return Swatch{Lab: colorspace.Lab{L: l}, Weight: weight}
+}
+
+func TestEMD(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Palette
+		want float64
+	}{
+		{name: "identical", a: Palette{gray(20, 0.3), gray(80, 0.7)}, b: Palette{gray(80, 0.7), gray(20, 0.3)}, want: 0},
+		{name: "single colors", a: Palette{{Lab: colorspace.Lab{L: 50, A: 3}, Weight: 1}}, b: Palette{{Lab: colorspace.Lab{L: 50, B: 4}, Weight: 1}}, want: 5},
+		{name: "unnormalized weights", a: Palette{gray(0, 2)}, b: Palette{gray(10, 0.5)}, want: 10},
+		{name: "split", a: Palette{gray(0, 0.5), gray(10, 0.5)}, b: Palette{gray(0, 1)}, want: 5},
+		{name: "shifted", a: Palette{gray(0, 0.5), gray(10, 0.5)}, b: Palette{gray(10, 0.5), gray(20, 0.5)}, want: 10},
+		{name: "uneven", a: Palette{gray(0, 0.75), gray(40, 0.25)}, b: Palette{gray(0, 0.25), gray(40, 0.75)}, want: 20},
+		{name: "without weights", a: Palette{gray(0, 0), gray(10, 0)}, b: Palette{gray(0, 0)}, want: 5},
+		{name: "zero weight color", a: Palette{gray(0, 1), gray(90, 0)}, b: Palette{gray(0, 1)}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EMD(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EMD(a, b)=%v, want %v", got, tt.want)
+			}
+			if got := EMD(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EMD(b, a)=%v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEMDEmpty(t *testing.T) {
+	for _, p := range []Palette{nil, {}} {
+		if got := EMD(p, Palette{gray(50, 1)}); !math.IsNaN(got) {
+			t.Errorf("EMD(%v, palette)=%v, want NaN", p, got)
+		}
+		if got := EMD(Palette{gray(50, 1)}, p); !math.IsNaN(got) {
+			t.Errorf("EMD(palette, %v)=%v, want NaN", p, got)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package similarity
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+
+	"github.com/kennykarnama/video-color-palette-generator/colorspace"
+	"github.com/kennykarnama/video-color-palette-generator/processor"
+)
+
+// VideoPalettes holds the palettes of one video read back from a result file.
+type VideoPalettes struct {
+	Path     string
+	Serial   string
+	URL      string
+	Segments []*SegmentPalette
+	// Summary is the stored summary palette (sample_number 0) or, without one,
+	// every segment palette pooled and weighted by segment duration.
+	Summary Palette
+}
+
+// SegmentPalette is the palette of one segment.
+type SegmentPalette struct {
+	Number   int
+	StartMs  int64
+	EndMs    int64
+	Duration float64
+	Palette  Palette
+}
+
+// Load reads the results written by processor.Run in csv, json or jsonl, picked by file extension
+// (csv for anything else). A csv can hold several videos since runs append to it.
+func Load(path string) ([]*VideoPalettes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("action=load path=%v err=%v", path, err)
+	}
+	defer f.Close()
+
+	c := newCollector(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = c.readJSON(f)
+	case ".jsonl":
+		err = c.readJSONLines(f)
+	default:
+		err = c.readCSV(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("action=load path=%v err=%v", path, err)
+	}
+	videos := c.videos()
+	if len(videos) == 0 {
+		return nil, fmt.Errorf("action=load path=%v err=no palettes found", path)
+	}
+	return videos, nil
+}
+
+// LoadAll loads every path, descending into folders for .csv, .json and .jsonl files.
+func LoadAll(paths []string) ([]*VideoPalettes, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("action=loadAll path=%v err=%v", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".csv", ".json", ".jsonl":
+				if !info.IsDir() {
+					files = append(files, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("action=loadAll path=%v err=%v", path, err)
+		}
+	}
+	var videos []*VideoPalettes
+	for _, file := range files {
+		loaded, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, loaded...)
+	}
+	return videos, nil
+}
+
+// collector groups rows by video and segment in the order they first appear. Runs appended to the
+// same file are kept apart: a segment number seen again for a video starts another VideoPalettes.
+type collector struct {
+	path       string
+	order      []*VideoPalettes
+	byVideo    map[string]*VideoPalettes
+	numbers    map[string]map[int]bool
+	bySegment  map[string]*SegmentPalette
+	hasSummary map[string]bool
+}
+
+func newCollector(path string) *collector {
+	return &collector{
+		path:       path,
+		byVideo:    map[string]*VideoPalettes{},
+		numbers:    map[string]map[int]bool{},
+		bySegment:  map[string]*SegmentPalette{},
+		hasSummary: map[string]bool{},
+	}
+}
+
+// video returns the current run of the video with serial and url.
+func (c *collector) video(serial, url string) (*VideoPalettes, string) {
+	key := serial + "\x00" + url
+	v, ok := c.byVideo[key]
+	if !ok {
+		v = c.newRun(key, serial, url)
+	}
+	return v, key
+}
+
+func (c *collector) newRun(key, serial, url string) *VideoPalettes {
+	v := &VideoPalettes{Path: c.path, Serial: serial, URL: url}
+	c.byVideo[key] = v
+	c.numbers[key] = map[int]bool{}
+	c.order = append(c.order, v)
+	return v
+}
+
+func (c *collector) segment(videoKey, segmentID string, number int, startMs, endMs int64, duration float64) *SegmentPalette {
+	key := videoKey + "\x00" + segmentID
+	s, ok := c.bySegment[key]
+	if !ok {
+		v := c.byVideo[videoKey]
+		if c.numbers[videoKey][number] {
+			// the same video extracted again and appended
+			v = c.newRun(videoKey, v.Serial, v.URL)
+		}
+		c.numbers[videoKey][number] = true
+		s = &SegmentPalette{Number: number, StartMs: startMs, EndMs: endMs, Duration: duration}
+		c.bySegment[key] = s
+		v.Segments = append(v.Segments, s)
+	}
+	return s
+}
+
+func (c *collector) readCSV(r io.Reader) error {
+	deduplicated, err := dropRepeatedHeaders(r)
+	if err != nil {
+		return err
+	}
+	var results []*processor.Result
+	if err := gocsv.Unmarshal(deduplicated, &results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		_, key := c.video(result.SourceSerial, result.SourceURL)
+		s := c.segment(key, result.SampleID, result.SampleNumber, result.SampleStartMs, result.SampleEndMs, result.SampleDuration)
+		clr := color.RGBA64{R: uint16(result.R), G: uint16(result.G), B: uint16(result.B), A: 0xffff}
+		s.Palette = append(s.Palette, Swatch{Lab: colorspace.ToLab(clr), Weight: result.Weight})
+	}
+	return nil
+}
+
+// dropRepeatedHeaders removes copies of the header row, which older runs wrote again on every append.
+func dropRepeatedHeaders(r io.Reader) (io.Reader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for i, record := range records {
+		if i > 0 && equalRecords(record, records[0]) {
+			continue
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return &buf, w.Error()
+}
+
+func equalRecords(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *collector) readJSON(r io.Reader) error {
+	var doc processor.ResultDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return err
+	}
+	if doc.Video == nil {
+		doc.Video = &processor.VideoRecord{}
+	}
+	for _, record := range doc.Segments {
+		c.addRecord(doc.Video, record)
+	}
+	return nil
+}
+
+func (c *collector) readJSONLines(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record processor.SegmentRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("line=%v err=%v", line, err)
+		}
+		video := record.Video
+		if video == nil {
+			video = &processor.VideoRecord{}
+		}
+		c.addRecord(video, &record)
+	}
+	return scanner.Err()
+}
+
+func (c *collector) addRecord(video *processor.VideoRecord, record *processor.SegmentRecord) {
+	_, key := c.video(video.Serial, video.URL)
+	s := c.segment(key, record.ID, record.Number, record.StartMs, record.EndMs, record.Duration)
+	for _, clr := range record.Colors {
+		rgb := color.RGBA{R: clr.R, G: clr.G, B: clr.B, A: 0xff}
+		s.Palette = append(s.Palette, Swatch{Lab: colorspace.ToLab(rgb), Weight: clr.Weight})
+	}
+}
+
+// videos moves summary segments out of the timeline and pools a summary for videos without one.
+func (c *collector) videos() []*VideoPalettes {
+	var videos []*VideoPalettes
+	for _, v := range c.order {
+		var segments []*SegmentPalette
+		for _, s := range v.Segments {
+			if len(s.Palette) == 0 {
+				continue
+			}
+			if s.Number == processor.SummaryNumber {
+				v.Summary = s.Palette
+				continue
+			}
+			segments = append(segments, s)
+		}
+		sort.SliceStable(segments, func(i, j int) bool { return segments[i].Number < segments[j].Number })
+		v.Segments = segments
+		if v.Summary == nil {
+			v.Summary = pool(segments)
+		}
+		if len(v.Summary) > 0 {
+			videos = append(videos, v)
+		}
+	}
+	return videos
+}
+
+// pool merges segment palettes into one, weighting each by its segment duration.
+func pool(segments []*SegmentPalette) Palette {
+	var pooled Palette
+	for _, s := range segments {
+		duration := s.Duration
+		if duration <= 0 {
+			duration = 1
+		}
+		for _, swatch := range s.Palette.normalized() {
+			pooled = append(pooled, Swatch{Lab: swatch.Lab, Weight: swatch.Weight * duration})
+		}
+	}
+	return pooled
+}
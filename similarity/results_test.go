@@ -0,0 +1,101 @@
+package similarity
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kennykarnama/video-color-palette-generator/processor"
+)
+
+func testSegment(serial string, number int, colors ...color.RGBA) *processor.Segment {
+	segment := &processor.Segment{
+		Video:    &processor.Video{Serial: serial, URL: "/videos/" + serial + ".mp4", DurationSeconds: 20, FPS: 25},
+		ID:       fmt.Sprintf("%v-%v", serial, number),
+		Number:   number,
+		Start:    time.Duration(number-1) * 10 * time.Second,
+		End:      time.Duration(number) * 10 * time.Second,
+		Duration: 10,
+	}
+	for _, clr := range colors {
+		segment.Colors = append(segment.Colors, processor.PaletteColor{Color: clr, Weight: 1 / float64(len(colors))})
+	}
+	return segment
+}
+
+// writeRun writes the segments of one run the way processor.Run does, appending when buf is not empty.
+func writeRun(t *testing.T, buf *bytes.Buffer, appending bool, segments ...*processor.Segment) {
+	t.Helper()
+	newSink := processor.NewSink
+	if appending {
+		newSink = processor.NewAppendingSink
+	}
+	sink, err := newSink(processor.FormatCSV, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, segment := range segments {
+		if err := sink.Write(segment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAppendedCSV(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	tests := []struct {
+		name string
+		// appending is false for files written before the header was skipped on append
+		appending bool
+	}{
+		{name: "header once", appending: true},
+		{name: "header repeated", appending: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeRun(t, &buf, false, testSegment("a", 1, red, blue), testSegment("a", 2, red))
+			writeRun(t, &buf, tt.appending, testSegment("b", 1, blue), testSegment("b", 2, blue, red))
+
+			path := filepath.Join(t.TempDir(), "result.csv")
+			if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+				t.Fatal(err)
+			}
+			videos, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() err=%v", err)
+			}
+			if len(videos) != 2 {
+				t.Fatalf("Load() got %v videos, want 2", len(videos))
+			}
+			for i, want := range []struct {
+				serial   string
+				swatches []int
+			}{
+				{serial: "a", swatches: []int{2, 1}},
+				{serial: "b", swatches: []int{1, 2}},
+			} {
+				video := videos[i]
+				if video.Serial != want.serial {
+					t.Errorf("video %v serial=%v, want %v", i, video.Serial, want.serial)
+				}
+				if len(video.Segments) != len(want.swatches) {
+					t.Fatalf("video %v got %v segments, want %v", want.serial, len(video.Segments), len(want.swatches))
+				}
+				for j, segment := range video.Segments {
+					if len(segment.Palette) != want.swatches[j] {
+						t.Errorf("video %v segment %v got %v swatches, want %v", want.serial, segment.Number, len(segment.Palette), want.swatches[j])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package similarity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+
+	"github.com/kennykarnama/video-color-palette-generator/processor"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+type CompareParameter struct {
+	Files  []string `arg:"positional,required" help:"result files (csv, json or jsonl). Every other video is compared with the first video of the first file"`
+	Output string   `arg:"--output,-o" help:"result path, stdout when empty"`
+	Format string   `arg:"--format,-f" default:"csv" help:"result format: csv or json"`
+}
+
+type SearchParameter struct {
+	Query  string   `arg:"--query,-q,required" help:"result file of the query video, its summary palette is searched for"`
+	Corpus []string `arg:"positional,required" help:"result files, or folders of .csv, .json and .jsonl results, to rank"`
+	Top    int      `arg:"--top,-n" default:"10" help:"number of matches to keep, 0 keeps all"`
+	Output string   `arg:"--output,-o" help:"result path, stdout when empty"`
+	Format string   `arg:"--format,-f" default:"csv" help:"result format: csv or json"`
+}
+
+// SegmentDistance is the palette distance between the segments with the same sample number.
+type SegmentDistance struct {
+	Number   int
+	StartMs  int64
+	EndMs    int64
+	Distance float64
+}
+
+// Comparison is the distance of a candidate video to a reference video.
+type Comparison struct {
+	Reference *VideoPalettes
+	Candidate *VideoPalettes
+	// Segments pairs segments by sample number, in reference order. Numbers missing from either video are skipped.
+	Segments []SegmentDistance
+	// Overall is the distance between the summary palettes.
+	Overall float64
+}
+
+// Compare measures how far the palettes of candidate are from reference, per segment and overall.
+func Compare(reference, candidate *VideoPalettes) *Comparison {
+	comparison := &Comparison{
+		Reference: reference,
+		Candidate: candidate,
+		Overall:   EMD(reference.Summary, candidate.Summary),
+	}
+	byNumber := make(map[int]*SegmentPalette, len(candidate.Segments))
+	for _, s := range candidate.Segments {
+		if _, ok := byNumber[s.Number]; !ok {
+			byNumber[s.Number] = s
+		}
+	}
+	for _, ref := range reference.Segments {
+		cand, ok := byNumber[ref.Number]
+		if !ok {
+			continue
+		}
+		comparison.Segments = append(comparison.Segments, SegmentDistance{
+			Number:   ref.Number,
+			StartMs:  ref.StartMs,
+			EndMs:    ref.EndMs,
+			Distance: EMD(ref.Palette, cand.Palette),
+		})
+	}
+	return comparison
+}
+
+// Match is a corpus video and its summary palette distance to the query.
+type Match struct {
+	Video    *VideoPalettes
+	Distance float64
+}
+
+// Search ranks corpus by summary palette distance to query, nearest first.
+func Search(query *VideoPalettes, corpus []*VideoPalettes) []Match {
+	matches := make([]Match, 0, len(corpus))
+	for _, video := range corpus {
+		matches = append(matches, Match{Video: video, Distance: EMD(query.Summary, video.Summary)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Distance < matches[j].Distance })
+	return matches
+}
+
+// CompareRow is one output row of the compare command. The overall distance uses sample_number 0,
+// like the summary palette.
+type CompareRow struct {
+	ReferenceSerial string  `csv:"reference_serial" json:"referenceSerial"`
+	ReferenceURL    string  `csv:"reference_url" json:"referenceURL"`
+	CandidateSerial string  `csv:"candidate_serial" json:"candidateSerial"`
+	CandidateURL    string  `csv:"candidate_url" json:"candidateURL"`
+	CandidatePath   string  `csv:"candidate_path" json:"candidatePath"`
+	SampleNumber    int     `csv:"sample_number" json:"sampleNumber"`
+	SampleStartMs   int64   `csv:"sample_start_ms" json:"sampleStartMs"`
+	SampleEndMs     int64   `csv:"sample_end_ms" json:"sampleEndMs"`
+	Distance        float64 `csv:"distance" json:"distance"`
+}
+
+// SearchRow is one output row of the search command.
+type SearchRow struct {
+	Rank     int     `csv:"rank" json:"rank"`
+	Serial   string  `csv:"source_serial" json:"serial"`
+	URL      string  `csv:"source_url" json:"url"`
+	Path     string  `csv:"path" json:"path"`
+	Distance float64 `csv:"distance" json:"distance"`
+}
+
+// Rows flattens the comparison, overall distance first.
+func (c *Comparison) Rows() []*CompareRow {
+	row := func(number int, startMs, endMs int64, distance float64) *CompareRow {
+		return &CompareRow{
+			ReferenceSerial: c.Reference.Serial,
+			ReferenceURL:    c.Reference.URL,
+			CandidateSerial: c.Candidate.Serial,
+			CandidateURL:    c.Candidate.URL,
+			CandidatePath:   c.Candidate.Path,
+			SampleNumber:    number,
+			SampleStartMs:   startMs,
+			SampleEndMs:     endMs,
+			Distance:        distance,
+		}
+	}
+	rows := []*CompareRow{row(processor.SummaryNumber, 0, 0, c.Overall)}
+	for _, s := range c.Segments {
+		rows = append(rows, row(s.Number, s.StartMs, s.EndMs, s.Distance))
+	}
+	return rows
+}
+
+// RunCompare compares every video in args.Files with the first one.
+func RunCompare(args CompareParameter) error {
+	if err := validFormat(args.Format); err != nil {
+		return err
+	}
+	videos, err := LoadAll(args.Files)
+	if err != nil {
+		return err
+	}
+	if len(videos) < 2 {
+		return fmt.Errorf("action=runCompare files=%v err=need at least two videos to compare, found %v", args.Files, len(videos))
+	}
+	var rows []*CompareRow
+	for _, candidate := range videos[1:] {
+		rows = append(rows, Compare(videos[0], candidate).Rows()...)
+	}
+	return writeRows(args.Output, args.Format, &rows)
+}
+
+// RunSearch ranks the corpus by similarity to the query video's summary palette.
+func RunSearch(args SearchParameter) error {
+	if err := validFormat(args.Format); err != nil {
+		return err
+	}
+	queries, err := Load(args.Query)
+	if err != nil {
+		return err
+	}
+	corpus, err := LoadAll(args.Corpus)
+	if err != nil {
+		return err
+	}
+	// a query file inside a corpus folder would otherwise match itself
+	var candidates []*VideoPalettes
+	for _, video := range corpus {
+		if !samePath(video.Path, args.Query) {
+			candidates = append(candidates, video)
+		}
+	}
+	matches := Search(queries[0], candidates)
+	if args.Top > 0 && len(matches) > args.Top {
+		matches = matches[:args.Top]
+	}
+	rows := []*SearchRow{}
+	for i, match := range matches {
+		rows = append(rows, &SearchRow{
+			Rank:     i + 1,
+			Serial:   match.Video.Serial,
+			URL:      match.Video.URL,
+			Path:     match.Video.Path,
+			Distance: match.Distance,
+		})
+	}
+	return writeRows(args.Output, args.Format, &rows)
+}
+
+func validFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "", FormatCSV, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("action=validFormat format=%v err=unknown format, available: %v, %v", format, FormatCSV, FormatJSON)
+	}
+}
+
+// writeRows writes a pointer to a slice of rows to path, or stdout when path is empty.
+func writeRows(path, format string, rows interface{}) error {
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("action=writeRows path=%v err=%v", path, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	var err error
+	if strings.EqualFold(format, FormatJSON) {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rows)
+	} else {
+		err = gocsv.Marshal(rows, w)
+	}
+	if err != nil {
+		return fmt.Errorf("action=writeRows path=%v err=%v", path, err)
+	}
+	return nil
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return absA == absB
+}
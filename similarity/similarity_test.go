@@ -0,0 +1,197 @@
+package similarity
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kennykarnama/video-color-palette-generator/processor"
+)
+
+// grays returns segments numbered numbers, each a single gray of lightness l.
+func grays(l float64, numbers ...int) []*SegmentPalette {
+	var segments []*SegmentPalette
+	for _, number := range numbers {
+		segments = append(segments, &SegmentPalette{Number: number, Duration: 10, Palette: Palette{gray(l, 1)}})
+	}
+	return segments
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name        string
+		reference   []*SegmentPalette
+		candidate   []*SegmentPalette
+		wantNumbers []int
+	}{
+		{name: "same segments", reference: grays(50, 1, 2, 3), candidate: grays(60, 1, 2, 3), wantNumbers: []int{1, 2, 3}},
+		{name: "candidate skipped a segment", reference: grays(50, 1, 2, 3), candidate: grays(60, 1, 3), wantNumbers: []int{1, 3}},
+		{name: "reference skipped a segment", reference: grays(50, 2, 3), candidate: grays(60, 1, 2, 3, 4), wantNumbers: []int{2, 3}},
+		{name: "shorter candidate", reference: grays(50, 1, 2, 3), candidate: grays(60, 1), wantNumbers: []int{1}},
+		{name: "no common segment", reference: grays(50, 1, 2), candidate: grays(60, 3, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reference := &VideoPalettes{Serial: "a", Segments: tt.reference, Summary: Palette{gray(50, 1)}}
+			candidate := &VideoPalettes{Serial: "b", Segments: tt.candidate, Summary: Palette{gray(70, 1)}}
+			comparison := Compare(reference, candidate)
+			if math.Abs(comparison.Overall-20) > 1e-9 {
+				t.Errorf("Overall=%v, want 20", comparison.Overall)
+			}
+			var numbers []int
+			for _, s := range comparison.Segments {
+				numbers = append(numbers, s.Number)
+				if math.Abs(s.Distance-10) > 1e-9 {
+					t.Errorf("segment %v distance=%v, want 10", s.Number, s.Distance)
+				}
+			}
+			if !reflect.DeepEqual(numbers, tt.wantNumbers) {
+				t.Errorf("segments=%v, want %v", numbers, tt.wantNumbers)
+			}
+			rows := comparison.Rows()
+			if len(rows) != len(tt.wantNumbers)+1 || rows[0].SampleNumber != processor.SummaryNumber {
+				t.Errorf("Rows() got %v rows, want the overall row and %v segments", len(rows), len(tt.wantNumbers))
+			}
+		})
+	}
+}
+
+func TestLoadAppendedRuns(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	rerun := func(number int, colors ...color.RGBA) *processor.Segment {
+		segment := testSegment("a", number, colors...)
+		segment.ID += "-rerun"
+		return segment
+	}
+	var buf bytes.Buffer
+	writeRun(t, &buf, false, testSegment("a", 1, red), testSegment("a", 2, blue))
+	// the same video again, with segment 1 missing
+	writeRun(t, &buf, true, rerun(2, blue), rerun(3, red))
+
+	path := filepath.Join(t.TempDir(), "result.csv")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	videos, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(videos) != 2 {
+		t.Fatalf("Load() got %v videos, want one per run", len(videos))
+	}
+	comparison := Compare(videos[0], videos[1])
+	if len(comparison.Segments) != 1 || comparison.Segments[0].Number != 2 || comparison.Segments[0].Distance > 1e-9 {
+		t.Errorf("segments=%+v, want segment 2 at distance 0", comparison.Segments)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	query := &VideoPalettes{Serial: "query", Summary: Palette{gray(50, 1)}}
+	corpus := []*VideoPalettes{
+		{Serial: "far", Summary: Palette{gray(90, 1)}},
+		{Serial: "same", Summary: Palette{gray(50, 1)}},
+		{Serial: "near", Summary: Palette{gray(55, 1)}},
+	}
+	var serials []string
+	for _, match := range Search(query, corpus) {
+		serials = append(serials, match.Video.Serial)
+	}
+	if want := []string{"same", "near", "far"}; !reflect.DeepEqual(serials, want) {
+		t.Errorf("Search()=%v, want %v", serials, want)
+	}
+}
+
+// writeResult writes the segments of one video as a csv result file in dir.
+func writeResult(t *testing.T, dir, name string, segments ...*processor.Segment) string {
+	t.Helper()
+	var buf bytes.Buffer
+	writeRun(t, &buf, false, segments...)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunCompare(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	dir := t.TempDir()
+	reference := writeResult(t, dir, "a.csv", testSegment("a", 1, red), testSegment("a", 2, red))
+	candidate := writeResult(t, dir, "b.csv", testSegment("b", 2, red))
+
+	out := filepath.Join(dir, "compare.json")
+	if err := RunCompare(CompareParameter{Files: []string{reference, candidate}, Output: out, Format: FormatJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var rows []*CompareRow
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].SampleNumber != processor.SummaryNumber || rows[1].SampleNumber != 2 {
+		t.Fatalf("rows=%+v, want the overall row and segment 2", rows)
+	}
+	if rows[1].ReferenceSerial != "a" || rows[1].CandidateSerial != "b" || rows[1].CandidatePath != candidate || rows[1].Distance > 1e-9 {
+		t.Errorf("segment row=%+v", rows[1])
+	}
+
+	if err := RunCompare(CompareParameter{Files: []string{reference}, Output: out}); err == nil {
+		t.Errorf("comparing a single video succeeded")
+	}
+	if err := RunCompare(CompareParameter{Files: []string{reference, candidate}, Output: out, Format: "xml"}); err == nil {
+		t.Errorf("unknown format succeeded")
+	}
+}
+
+func TestRunSearch(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	orange := color.RGBA{R: 255, G: 100, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	corpus := t.TempDir()
+	// the query is inside the corpus folder and must not match itself
+	query := writeResult(t, corpus, "query.csv", testSegment("query", 1, red))
+	writeResult(t, corpus, "blue.csv", testSegment("blue", 1, blue))
+	writeResult(t, corpus, "orange.csv", testSegment("orange", 1, orange))
+	writeResult(t, corpus, "red.csv", testSegment("red", 1, red))
+
+	tests := []struct {
+		top  int
+		want []string
+	}{
+		{top: 0, want: []string{"red", "orange", "blue"}},
+		{top: 2, want: []string{"red", "orange"}},
+	}
+	for _, tt := range tests {
+		out := filepath.Join(t.TempDir(), "search.json")
+		if err := RunSearch(SearchParameter{Query: query, Corpus: []string{corpus}, Top: tt.top, Output: out, Format: FormatJSON}); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rows []*SearchRow
+		if err := json.Unmarshal(data, &rows); err != nil {
+			t.Fatal(err)
+		}
+		var serials []string
+		for i, row := range rows {
+			serials = append(serials, row.Serial)
+			if row.Rank != i+1 {
+				t.Errorf("row %v rank=%v", i, row.Rank)
+			}
+		}
+		if !reflect.DeepEqual(serials, tt.want) {
+			t.Errorf("top %v: RunSearch()=%v, want %v", tt.top, serials, tt.want)
+		}
+	}
+}
@@ -1,14 +1,30 @@
 package source
 
 import (
-	"log"
+	sharedS3Internal "github.com/kennykarnama/video-color-palette-generator/shared/s3"
+
 	"fmt"
 )
 
+func init() {
+	Register(fileScheme, func(uri string) (Provider, error) { return NewLocalSourceFromURI(uri) })
+	Register(sharedS3Internal.S3Scheme, func(uri string) (Provider, error) { return NewS3SourceFromURI(uri) })
+	Register("http", func(uri string) (Provider, error) { return NewHTTPSourceFromURI(uri) })
+	Register("https", func(uri string) (Provider, error) { return NewHTTPSourceFromURI(uri) })
+	// S3 HTTPS URLs and URLs of a custom S3 endpoint
+	RegisterMatcher("s3 https url", sharedS3Internal.IsHTTPURL, func(uri string) (Provider, error) { return NewS3SourceFromURI(uri) })
+}
+
+// GetProvider returns the Provider registered for sourceURI, see Register and RegisterMatcher.
 func GetProvider(sourceURI string) (Provider, error) {
-	if s3Source, err := NewS3SourceFromURI(sourceURI); err == nil {
-		log.Printf("GetProvider.s3 err=%v", err)
-		return s3Source, nil
+	factory := lookup(sourceURI)
+	if factory == nil {
+		return nil, fmt.Errorf("GetProvider sourceURI=%v err=unsupported scheme %q, %v",
+			sourceURI, providers.Scheme(sourceURI), providers.Supported())
+	}
+	provider, err := factory(sourceURI)
+	if err != nil {
+		return nil, fmt.Errorf("GetProvider sourceURI=%v err=%v", sourceURI, err)
 	}
-	return nil, fmt.Errorf("GetProvider sourceURI=%v err=undefined sourceURI", sourceURI)
+	return provider, nil
 }
@@ -0,0 +1,289 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultHTTPMaxRetries   = 3
+	DefaultHTTPRetryBackoff = time.Second
+	DefaultHTTPDownloadDir  = "/tmp"
+)
+
+// DefaultHTTPContentTypes are the media types NewHTTPSourceFromURI accepts.
+// Object stores and CDNs often serve videos as octet streams.
+var DefaultHTTPContentTypes = []string{"video/*", "application/octet-stream", "binary/octet-stream"}
+
+// HTTPSource fetches a video from a generic HTTP(S) URL, like a CDN link or a presigned URL.
+type HTTPSource struct {
+	URL *url.URL
+	// Client sends the requests. When nil, a client refusing blocked addresses is used,
+	// see AllowPrivateNetworks; a given Client does its own dialing and only the host lists apply.
+	Client *http.Client
+	// MaxRetries is the number of attempts after the first one on network errors, 429 and 5xx responses.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled on every following retry.
+	RetryBackoff time.Duration
+	// MaxBytes rejects videos larger than this, no limit when 0.
+	MaxBytes int64
+	// AllowedContentTypes lists the accepted media types, "video/*" style wildcards allowed.
+	// Any type is accepted when empty, and so is a response without Content-Type.
+	AllowedContentTypes []string
+	// Stream skips the download: LocalURI returns the URL itself for gocv and ffprobe to read.
+	// It requires AllowPrivateNetworks, since those tools connect without the address checks.
+	Stream bool
+	// Dir is where downloads go, DefaultHTTPDownloadDir when empty.
+	Dir string
+	// AllowedHosts, when not empty, lists the only hosts videos are fetched from, redirects included.
+	// "example.com" matches that host, ".example.com" and "*.example.com" its subdomains.
+	AllowedHosts []string
+	// DeniedHosts lists hosts never fetched from, in the same format. It wins over AllowedHosts.
+	DeniedHosts []string
+	// AllowPrivateNetworks permits loopback, link-local (like the instance metadata endpoint 169.254.169.254),
+	// private and other internal addresses, all refused by default.
+	AllowPrivateNetworks bool
+}
+
+// NewHTTPSourceFromURI accepts http and https URLs, with default retries and content types
+// and the host lists of HTTPHostsFromEnv.
+func NewHTTPSourceFromURI(uri string) (*HTTPSource, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("action=newHTTPSourceFromURI uri=%v err=%v", uri, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("action=newHTTPSourceFromURI uri=%v err=not an http(s) url", uri)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("action=newHTTPSourceFromURI uri=%v err=missing host", uri)
+	}
+	allowed, denied, allowPrivate := HTTPHostsFromEnv()
+	return &HTTPSource{
+		URL:                  u,
+		MaxRetries:           DefaultHTTPMaxRetries,
+		RetryBackoff:         DefaultHTTPRetryBackoff,
+		AllowedContentTypes:  DefaultHTTPContentTypes,
+		AllowedHosts:         allowed,
+		DeniedHosts:          denied,
+		AllowPrivateNetworks: allowPrivate,
+	}, nil
+}
+
+// permanentError stops the retries.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// LocalURI downloads the video to Dir, resuming with Range requests after a failed attempt.
+// Streams are refused unless AllowPrivateNetworks is set: gocv and ffprobe resolve the host again and
+// follow redirects on their own, out of reach of the address checks.
+func (s *HTTPSource) LocalURI(ctx context.Context, uri string) (string, error) {
+	if err := s.checkHost(s.URL.Hostname()); err != nil {
+		return "", fmt.Errorf("action=httpSource.LocalURI uri=%v err=%v", uri, err)
+	}
+	if s.Stream {
+		if !s.AllowPrivateNetworks {
+			return "", fmt.Errorf("action=httpSource.LocalURI uri=%v err=%v", uri,
+				newPolicyError("streaming bypasses the private network checks, it needs AllowPrivateNetworks"))
+		}
+		return s.URL.String(), nil
+	}
+	dir := s.Dir
+	if dir == "" {
+		dir = DefaultHTTPDownloadDir
+	}
+	f, err := os.CreateTemp(dir, "source-*"+path.Ext(s.URL.Path))
+	if err != nil {
+		return "", fmt.Errorf("action=httpSource.LocalURI uri=%v err=%v", uri, err)
+	}
+	localURI := f.Name()
+
+	client := s.httpClient()
+	backoff := s.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = s.download(ctx, client, f)
+		if err == nil {
+			break
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= s.MaxRetries || ctx.Err() != nil {
+			break
+		}
+		log.Printf("action=httpSource.LocalURI uri=%v attempt=%v err=%v, retrying in %v", uri, attempt+1, err, backoff)
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(backoff):
+			backoff *= 2
+			continue
+		}
+		break
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(localURI)
+		return "", fmt.Errorf("action=httpSource.LocalURI uri=%v target=%v err=%v", uri, localURI, err)
+	}
+	return localURI, nil
+}
+
+// download fetches the rest of the video into f, from its current size on.
+func (s *HTTPSource) download(ctx context.Context, client *http.Client, f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return &permanentError{err}
+	}
+	offset := info.Size()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL.String(), nil)
+	if err != nil {
+		return &permanentError{err}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var policy *policyError
+		if errors.As(err, &policy) {
+			return &permanentError{err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// no or ignored Range, start over
+		offset = 0
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, _, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			// not the requested part, drop it and start over on the next attempt
+			if err := truncate(f, 0); err != nil {
+				return &permanentError{err}
+			}
+			return fmt.Errorf("unexpected Content-Range %q for offset %v", resp.Header.Get("Content-Range"), offset)
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if _, total, err := parseContentRange(resp.Header.Get("Content-Range")); err == nil && total == offset {
+			return nil
+		}
+		if err := truncate(f, 0); err != nil {
+			return &permanentError{err}
+		}
+		return fmt.Errorf("range from %v not satisfiable", offset)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("status=%v", resp.Status)
+	default:
+		return &permanentError{fmt.Errorf("status=%v", resp.Status)}
+	}
+
+	if err := s.checkContentType(resp.Header.Get("Content-Type")); err != nil {
+		return &permanentError{err}
+	}
+	if s.MaxBytes > 0 && resp.ContentLength >= 0 && offset+resp.ContentLength > s.MaxBytes {
+		return &permanentError{fmt.Errorf("size=%v exceeds max=%v", offset+resp.ContentLength, s.MaxBytes)}
+	}
+	if err := truncate(f, offset); err != nil {
+		return &permanentError{err}
+	}
+
+	body := io.Reader(resp.Body)
+	if s.MaxBytes > 0 {
+		// one byte more than allowed tells an oversized body without Content-Length apart
+		body = io.LimitReader(resp.Body, s.MaxBytes-offset+1)
+	}
+	n, err := io.Copy(f, body)
+	if err != nil {
+		return err
+	}
+	if s.MaxBytes > 0 && offset+n > s.MaxBytes {
+		return &permanentError{fmt.Errorf("size exceeds max=%v", s.MaxBytes)}
+	}
+	if resp.ContentLength >= 0 && n < resp.ContentLength {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (s *HTTPSource) checkContentType(contentType string) error {
+	if contentType == "" || len(s.AllowedContentTypes) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("content-type=%v err=%v", contentType, err)
+	}
+	for _, allowed := range s.AllowedContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return nil
+		}
+	}
+	return fmt.Errorf("content-type=%v err=not one of %v", contentType, s.AllowedContentTypes)
+}
+
+// Cleanup removes the downloaded file. Streamed URLs leave nothing behind.
+func (s *HTTPSource) Cleanup(localURI string) error {
+	if s.Stream || localURI == s.URL.String() {
+		return nil
+	}
+	if err := os.Remove(localURI); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("action=httpSource.Cleanup localURI=%v err=%v", localURI, err)
+	}
+	return nil
+}
+
+func truncate(f *os.File, size int64) error {
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	_, err := f.Seek(size, io.SeekStart)
+	return err
+}
+
+// parseContentRange reads "bytes start-end/total" or "bytes */total". total is -1 when unknown.
+func parseContentRange(value string) (start, total int64, err error) {
+	spec := strings.TrimPrefix(value, "bytes ")
+	slash := strings.LastIndexByte(spec, '/')
+	if spec == value || slash < 0 {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	total = -1
+	if t := spec[slash+1:]; t != "*" {
+		if total, err = strconv.ParseInt(t, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+		}
+	}
+	rng := spec[:slash]
+	if rng == "*" {
+		return -1, total, nil
+	}
+	dash := strings.IndexByte(rng, '-')
+	if dash < 0 {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	if start, err = strconv.ParseInt(rng[:dash], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	return start, total, nil
+}
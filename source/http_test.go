@@ -0,0 +1,559 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testVideo = bytes.Repeat([]byte("0123456789"), 1000)
+
+// dropConnection writes a raw response announcing contentLength bytes, sends body and closes the connection.
+func dropConnection(t *testing.T, w http.ResponseWriter, status string, header map[string]string, contentLength int, body []byte) {
+	t.Helper()
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	fmt.Fprintf(buf, "HTTP/1.1 %v\r\nContent-Length: %d\r\n", status, contentLength)
+	for k, v := range header {
+		fmt.Fprintf(buf, "%v: %v\r\n", k, v)
+	}
+	fmt.Fprint(buf, "\r\n")
+	buf.Write(body)
+	buf.Flush()
+}
+
+// rangeOffset returns the start of a "bytes=N-" Range header, 0 without one.
+func rangeOffset(t *testing.T, r *http.Request) int {
+	t.Helper()
+	var offset int
+	if rng := r.Header.Get("Range"); rng != "" {
+		if _, err := fmt.Sscanf(rng, "bytes=%d-", &offset); err != nil {
+			t.Errorf("Range=%q err=%v", rng, err)
+		}
+	}
+	return offset
+}
+
+// newTestHTTPSource returns a source for path on srv that retries quickly and may dial loopback.
+func newTestHTTPSource(t *testing.T, srv *httptest.Server, path string) *HTTPSource {
+	t.Helper()
+	s, err := NewHTTPSourceFromURI(srv.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.RetryBackoff = time.Millisecond
+	s.AllowPrivateNetworks = true
+	s.Dir = t.TempDir()
+	return s
+}
+
+func TestHTTPSourceLocalURI(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  func(t *testing.T, call int, w http.ResponseWriter, r *http.Request)
+		maxBytes int64
+		wantErr  string
+		// wantCalls is the number of requests the server should get
+		wantCalls int32
+	}{
+		{
+			name: "ok",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Write(testVideo)
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "retries 5xx",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				if call < 3 {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Write(testVideo)
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "retries 429",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				if call == 1 {
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Write(testVideo)
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "gives up after max retries",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			wantErr:   "503",
+			wantCalls: 1 + DefaultHTTPMaxRetries,
+		},
+		{
+			name: "resumes with range",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				offset := rangeOffset(t, r)
+				if call == 1 {
+					if offset != 0 {
+						t.Errorf("first request has Range offset %v", offset)
+					}
+					dropConnection(t, w, "200 OK", map[string]string{"Content-Type": "video/mp4"}, len(testVideo), testVideo[:4000])
+					return
+				}
+				if offset != 4000 {
+					t.Errorf("resumed at %v, want 4000", offset)
+				}
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(testVideo)-1, len(testVideo)))
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write(testVideo[offset:])
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "restarts when range is ignored",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				if call == 1 {
+					dropConnection(t, w, "200 OK", map[string]string{"Content-Type": "video/mp4"}, len(testVideo), testVideo[:4000])
+					return
+				}
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Write(testVideo)
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "416 on a complete file",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				if call == 1 {
+					// announces more than the whole video and drops after it
+					dropConnection(t, w, "200 OK", map[string]string{"Content-Type": "video/mp4"}, len(testVideo)+10, testVideo)
+					return
+				}
+				if offset := rangeOffset(t, r); offset != len(testVideo) {
+					t.Errorf("resumed at %v, want %v", offset, len(testVideo))
+				}
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(testVideo)))
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "rejects content type",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Write(testVideo)
+			},
+			wantErr:   "content-type",
+			wantCalls: 1,
+		},
+		{
+			name: "does not retry 404",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			wantErr:   "404",
+			wantCalls: 1,
+		},
+		{
+			name: "max bytes with content length",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Header().Set("Content-Length", fmt.Sprint(len(testVideo)))
+				w.Write(testVideo)
+			},
+			maxBytes:  5000,
+			wantErr:   "exceeds max=5000",
+			wantCalls: 1,
+		},
+		{
+			name: "max bytes without content length",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				// flushing first makes the response chunked
+				w.(http.Flusher).Flush()
+				w.Write(testVideo)
+			},
+			maxBytes:  5000,
+			wantErr:   "exceeds max=5000",
+			wantCalls: 1,
+		},
+		{
+			name: "max bytes equal to the size",
+			handler: func(t *testing.T, call int, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				w.(http.Flusher).Flush()
+				w.Write(testVideo)
+			},
+			maxBytes:  int64(len(testVideo)),
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(t, int(atomic.AddInt32(&calls, 1)), w, r)
+			}))
+			defer srv.Close()
+
+			s := newTestHTTPSource(t, srv, "/video.mp4")
+			s.MaxBytes = tt.maxBytes
+			localURI, err := s.LocalURI(context.Background(), srv.URL+"/video.mp4")
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("server got %v requests, want %v", got, tt.wantCalls)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err=%v, want it to contain %q", err, tt.wantErr)
+				}
+				if entries, _ := os.ReadDir(s.Dir); len(entries) != 0 {
+					t.Errorf("failed download left %v files behind", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(localURI, ".mp4") {
+				t.Errorf("localURI=%v, want the .mp4 extension kept", localURI)
+			}
+			got, err := os.ReadFile(localURI)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, testVideo) {
+				t.Errorf("downloaded %v bytes, want the %v bytes of the video", len(got), len(testVideo))
+			}
+			if err := s.Cleanup(localURI); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(localURI); !os.IsNotExist(err) {
+				t.Errorf("Cleanup left %v, stat err=%v", localURI, err)
+			}
+		})
+	}
+}
+
+func TestHTTPSourceCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	s := newTestHTTPSource(t, srv, "/video.mp4")
+	s.RetryBackoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := s.LocalURI(ctx, srv.URL+"/video.mp4")
+	// LocalURI formats the context error into its own
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("err=%v, want the deadline", err)
+	}
+}
+
+func TestHTTPSourceStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("stream should not be downloaded, got %v", r.URL)
+	}))
+	defer srv.Close()
+
+	s := newTestHTTPSource(t, srv, "/video.mp4")
+	s.Stream = true
+	localURI, err := s.LocalURI(context.Background(), srv.URL+"/video.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if localURI != srv.URL+"/video.mp4" {
+		t.Errorf("localURI=%v, want the url itself", localURI)
+	}
+	if err := s.Cleanup(localURI); err != nil {
+		t.Errorf("Cleanup err=%v", err)
+	}
+
+	// without AllowPrivateNetworks any stream is refused, public hosts too since a redirect or
+	// another resolution could still lead inside
+	for _, uri := range []string{srv.URL + "/video.mp4", "http://example.com/video.mp4"} {
+		s, err := NewHTTPSourceFromURI(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Stream = true
+		if _, err := s.LocalURI(context.Background(), uri); err == nil {
+			t.Errorf("streaming %v without AllowPrivateNetworks succeeded", uri)
+		}
+	}
+}
+
+func TestHTTPSourceRefusesPrivateNetworks(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write(testVideo)
+	}))
+	defer srv.Close()
+
+	s := newTestHTTPSource(t, srv, "/video.mp4")
+	s.AllowPrivateNetworks = false
+	_, err := s.LocalURI(context.Background(), srv.URL+"/video.mp4")
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("err=%v, want the loopback address refused", err)
+	}
+	if calls != 0 {
+		t.Errorf("server got %v requests, want none", calls)
+	}
+}
+
+func TestHTTPSourceHosts(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/redirect" {
+			// same server under another name
+			http.Redirect(w, r, strings.Replace(srvURL(r), "127.0.0.1", "localhost", 1)+"/video.mp4", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write(testVideo)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		allowed []string
+		denied  []string
+		wantErr bool
+	}{
+		{name: "no lists", path: "/video.mp4"},
+		{name: "allowed", path: "/video.mp4", allowed: []string{"example.com", "127.0.0.1"}},
+		{name: "not allowed", path: "/video.mp4", allowed: []string{"example.com"}, wantErr: true},
+		{name: "denied", path: "/video.mp4", denied: []string{"127.0.0.1"}, wantErr: true},
+		{name: "denied wins", path: "/video.mp4", allowed: []string{"127.0.0.1"}, denied: []string{"127.0.0.1"}, wantErr: true},
+		{name: "redirect to allowed host", path: "/redirect", allowed: []string{"127.0.0.1", "localhost"}},
+		{name: "redirect to other host", path: "/redirect", allowed: []string{"127.0.0.1"}, wantErr: true},
+		{name: "redirect to denied host", path: "/redirect", denied: []string{"localhost"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+			s := newTestHTTPSource(t, srv, tt.path)
+			s.AllowedHosts, s.DeniedHosts = tt.allowed, tt.denied
+			localURI, err := s.LocalURI(context.Background(), srv.URL+tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LocalURI succeeded, want the host refused")
+				}
+				if n := atomic.LoadInt32(&calls); n > 1 {
+					t.Errorf("refused host was retried, %v requests", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Cleanup(localURI)
+		})
+	}
+}
+
+func srvURL(r *http.Request) string {
+	return "http://" + r.Host
+}
+
+// setenv sets key for the rest of the test.
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+	prev, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestNewHTTPSourceFromURIEnv(t *testing.T) {
+	setenv(t, "HTTP_SOURCE_ALLOWED_HOSTS", " cdn.example.com, .media.example.com ,")
+	setenv(t, "HTTP_SOURCE_DENIED_HOSTS", "bad.media.example.com")
+	setenv(t, "HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS", "")
+	s, err := NewHTTPSourceFromURI("https://cdn.example.com/a.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cdn.example.com", ".media.example.com"}; fmt.Sprint(s.AllowedHosts) != fmt.Sprint(want) {
+		t.Errorf("AllowedHosts=%q, want %q", s.AllowedHosts, want)
+	}
+	if want := []string{"bad.media.example.com"}; fmt.Sprint(s.DeniedHosts) != fmt.Sprint(want) {
+		t.Errorf("DeniedHosts=%q, want %q", s.DeniedHosts, want)
+	}
+	if s.AllowPrivateNetworks {
+		t.Errorf("AllowPrivateNetworks=true, want false by default")
+	}
+}
+
+func TestNewHTTPSourceFromURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr bool
+	}{
+		{uri: "https://cdn.example.com/a.mp4"},
+		{uri: "http://cdn.example.com:8080/a.mp4?sig=1"},
+		{uri: "ftp://cdn.example.com/a.mp4", wantErr: true},
+		{uri: "https:///a.mp4", wantErr: true},
+		{uri: "/tmp/a.mp4", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := NewHTTPSourceFromURI(tt.uri)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewHTTPSourceFromURI(%q) err=%v, wantErr %v", tt.uri, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	s := &HTTPSource{AllowedContentTypes: DefaultHTTPContentTypes}
+	tests := []struct {
+		contentType string
+		wantErr     bool
+	}{
+		{contentType: ""},
+		{contentType: "video/mp4"},
+		{contentType: "Video/MP4; codecs=avc1"},
+		{contentType: "application/octet-stream"},
+		{contentType: "binary/octet-stream"},
+		{contentType: "text/html", wantErr: true},
+		{contentType: "videos/mp4", wantErr: true},
+		{contentType: "not a media type;;", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := s.checkContentType(tt.contentType); (err != nil) != tt.wantErr {
+			t.Errorf("checkContentType(%q) err=%v, wantErr %v", tt.contentType, err, tt.wantErr)
+		}
+	}
+	if err := (&HTTPSource{}).checkContentType("text/html"); err != nil {
+		t.Errorf("empty AllowedContentTypes should accept anything, err=%v", err)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantStart int64
+		wantTotal int64
+		wantErr   bool
+	}{
+		{value: "bytes 0-99/100", wantStart: 0, wantTotal: 100},
+		{value: "bytes 4000-9999/10000", wantStart: 4000, wantTotal: 10000},
+		{value: "bytes 5-9/*", wantStart: 5, wantTotal: -1},
+		{value: "bytes */100", wantStart: -1, wantTotal: 100},
+		{value: "", wantErr: true},
+		{value: "0-99/100", wantErr: true},
+		{value: "bytes 0-99", wantErr: true},
+		{value: "bytes x-99/100", wantErr: true},
+		{value: "bytes 0-99/x", wantErr: true},
+		{value: "bytes 099/100", wantErr: true},
+	}
+	for _, tt := range tests {
+		start, total, err := parseContentRange(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseContentRange(%q) err=%v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (start != tt.wantStart || total != tt.wantTotal) {
+			t.Errorf("parseContentRange(%q)=%v, %v, want %v, %v", tt.value, start, total, tt.wantStart, tt.wantTotal)
+		}
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	patterns := []string{"cdn.example.com", ".media.example.com", "*.videos.example.org"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "cdn.example.com", want: true},
+		{host: "CDN.Example.com.", want: true},
+		{host: "a.cdn.example.com", want: false},
+		{host: "eu.media.example.com", want: true},
+		{host: "media.example.com", want: false},
+		{host: "evilmedia.example.com", want: false},
+		{host: "x.videos.example.org", want: true},
+		{host: "example.com", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchHost(tt.host, patterns); got != tt.want {
+			t.Errorf("matchHost(%q)=%v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "169.254.169.254", want: true},
+		{ip: "127.0.0.1", want: true},
+		{ip: "10.1.2.3", want: true},
+		{ip: "172.20.0.1", want: true},
+		{ip: "192.168.1.1", want: true},
+		{ip: "100.64.0.1", want: true},
+		{ip: "0.0.0.0", want: true},
+		{ip: "::1", want: true},
+		{ip: "fe80::1", want: true},
+		{ip: "fd00::1", want: true},
+		{ip: "::ffff:169.254.169.254", want: true},
+		{ip: "8.8.8.8", want: false},
+		{ip: "172.32.0.1", want: false},
+		{ip: "2606:4700::1111", want: false},
+	}
+	for _, tt := range tests {
+		if got := blockedIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("blockedIP(%v)=%v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPSourceKeepsQuery(t *testing.T) {
+	// presigned urls only work with their query untouched
+	query := "X-Amz-Signature=a%2Fb&X-Amz-Expires=60"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != query {
+			t.Errorf("server got query %q, want %q", r.URL.RawQuery, query)
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write(testVideo)
+	}))
+	defer srv.Close()
+
+	s := newTestHTTPSource(t, srv, "/video.mp4?"+query)
+	localURI, err := s.LocalURI(context.Background(), s.URL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Cleanup(localURI)
+}
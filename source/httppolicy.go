@@ -0,0 +1,151 @@
+package source
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// policyError is a request refused by the host lists or the address check. It is never retried.
+type policyError struct {
+	msg string
+}
+
+func (e *policyError) Error() string {
+	return e.msg
+}
+
+func newPolicyError(format string, args ...interface{}) error {
+	return &policyError{msg: fmt.Sprintf(format, args...)}
+}
+
+// blockedNetworks are the private and shared address ranges not covered by the net.IP predicates used in blockedIP.
+var blockedNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",      // this network
+		"10.0.0.0/8",     // private
+		"100.64.0.0/10",  // carrier grade nat
+		"172.16.0.0/12",  // private
+		"192.168.0.0/16", // private
+		"198.18.0.0/15",  // benchmarking
+		"fc00::/7",       // unique local
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// blockedIP reports whether ip is loopback, link-local (169.254.169.254 instance metadata included),
+// private, multicast or unspecified.
+func blockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPHostsFromEnv reads the host lists of NewHTTPSourceFromURI: HTTP_SOURCE_ALLOWED_HOSTS and
+// HTTP_SOURCE_DENIED_HOSTS, comma separated, and HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS.
+func HTTPHostsFromEnv() (allowed, denied []string, allowPrivate bool) {
+	allowPrivate, _ = strconv.ParseBool(os.Getenv("HTTP_SOURCE_ALLOW_PRIVATE_NETWORKS"))
+	return splitHosts(os.Getenv("HTTP_SOURCE_ALLOWED_HOSTS")), splitHosts(os.Getenv("HTTP_SOURCE_DENIED_HOSTS")), allowPrivate
+}
+
+func splitHosts(value string) []string {
+	var hosts []string
+	for _, host := range strings.Split(value, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// matchHost reports whether host is one of patterns. "example.com" matches that host only,
+// ".example.com" and "*.example.com" match its subdomains.
+func matchHost(host string, patterns []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimPrefix(pattern, "*"))
+		if strings.HasPrefix(pattern, ".") {
+			if strings.HasSuffix(host, pattern) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost applies the allow and deny lists to host.
+func (s *HTTPSource) checkHost(host string) error {
+	if matchHost(host, s.DeniedHosts) {
+		return newPolicyError("host=%v err=host is denied", host)
+	}
+	if len(s.AllowedHosts) > 0 && !matchHost(host, s.AllowedHosts) {
+		return newPolicyError("host=%v err=host is not allowed, allowed hosts: %v", host, strings.Join(s.AllowedHosts, ", "))
+	}
+	return nil
+}
+
+// httpClient returns the client to send requests with. Redirects go through the host lists too.
+// Without a Client, the default one refuses to connect to blocked addresses, checked after name
+// resolution so a host cannot resolve to another address between check and connect.
+func (s *HTTPSource) httpClient() *http.Client {
+	var client http.Client
+	if s.Client != nil {
+		client = *s.Client
+	} else {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if !s.AllowPrivateNetworks {
+			dialer.Control = func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+					return newPolicyError("address=%v err=private, loopback and link-local addresses are not allowed", host)
+				}
+				return nil
+			}
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = dialer.DialContext
+		// a proxy would be dialed instead of the video host, defeating the address check
+		transport.Proxy = nil
+		client.Transport = transport
+	}
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := s.checkHost(req.URL.Hostname()); err != nil {
+			return err
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &client
+}
@@ -0,0 +1,57 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const fileScheme = "file"
+
+// LocalSource reads a video already on disk or on a mounted volume, in place.
+type LocalSource struct {
+	Path string
+}
+
+// NewLocalSourceFromURI accepts a plain path or a file:// URI (file:///path or file://localhost/path).
+func NewLocalSourceFromURI(uri string) (*LocalSource, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("action=newLocalSourceFromURI uri=%v err=empty path", uri)
+	}
+	if !strings.Contains(uri, "://") {
+		return &LocalSource{Path: uri}, nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("action=newLocalSourceFromURI uri=%v err=%v", uri, err)
+	}
+	if !strings.EqualFold(u.Scheme, fileScheme) {
+		return nil, fmt.Errorf("action=newLocalSourceFromURI uri=%v err=not a file uri", uri)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("action=newLocalSourceFromURI uri=%v err=remote host %v is not supported", uri, u.Host)
+	}
+	if u.Path == "" {
+		return nil, fmt.Errorf("action=newLocalSourceFromURI uri=%v err=empty path", uri)
+	}
+	return &LocalSource{Path: u.Path}, nil
+}
+
+// LocalURI returns the path itself after checking it is a readable regular file.
+func (s *LocalSource) LocalURI(ctx context.Context, uri string) (string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return "", fmt.Errorf("action=localSource.LocalURI uri=%v path=%v err=%v", uri, s.Path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("action=localSource.LocalURI uri=%v path=%v err=not a regular file", uri, s.Path)
+	}
+	return s.Path, nil
+}
+
+// Cleanup leaves the original file untouched.
+func (s *LocalSource) Cleanup(localURI string) error {
+	return nil
+}
@@ -0,0 +1,92 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewLocalSourceFromURI(t *testing.T) {
+	tests := []struct {
+		uri      string
+		wantPath string
+		wantErr  bool
+	}{
+		{uri: "/videos/a.mp4", wantPath: "/videos/a.mp4"},
+		{uri: "videos/a.mp4", wantPath: "videos/a.mp4"},
+		{uri: "file:///videos/a.mp4", wantPath: "/videos/a.mp4"},
+		{uri: "FILE:///videos/a.mp4", wantPath: "/videos/a.mp4"},
+		{uri: "file://localhost/videos/a.mp4", wantPath: "/videos/a.mp4"},
+		{uri: "file:///videos/my%20video%231.mp4", wantPath: "/videos/my video#1.mp4"},
+		{uri: "file://fileserver/videos/a.mp4", wantErr: true},
+		{uri: "http://example.com/a.mp4", wantErr: true},
+		{uri: "", wantErr: true},
+		{uri: "file://", wantErr: true},
+		{uri: "file://localhost", wantErr: true},
+	}
+	for _, tt := range tests {
+		s, err := NewLocalSourceFromURI(tt.uri)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NewLocalSourceFromURI(%q)=%+v, want error", tt.uri, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewLocalSourceFromURI(%q) err=%v", tt.uri, err)
+			continue
+		}
+		if s.Path != tt.wantPath {
+			t.Errorf("NewLocalSourceFromURI(%q) path=%q, want %q", tt.uri, s.Path, tt.wantPath)
+		}
+	}
+}
+
+func TestLocalSourceLocalURI(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "a.mp4")
+	if err := os.WriteFile(video, testVideo, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		uri     string
+		wantErr bool
+	}{
+		{name: "plain path", uri: video},
+		{name: "file uri", uri: "file://" + video},
+		{name: "directory", uri: dir, wantErr: true},
+		{name: "missing file", uri: filepath.Join(dir, "missing.mp4"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewLocalSourceFromURI(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			localURI, err := s.LocalURI(context.Background(), tt.uri)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("LocalURI()=%v, want error", localURI)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if localURI != video {
+				t.Errorf("LocalURI()=%v, want the file itself %v", localURI, video)
+			}
+
+			// the original is read in place and never removed
+			if err := s.Cleanup(localURI); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(video); err != nil {
+				t.Errorf("Cleanup removed the original, stat err=%v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package source
+
+import (
+	"github.com/kennykarnama/video-color-palette-generator/internal/registry"
+)
+
+// Factory builds the Provider of uri.
+type Factory func(uri string) (Provider, error)
+
+// Matcher reports whether its factory handles uri.
+type Matcher func(uri string) bool
+
+// providers holds Factory values. Plain paths have the "file" scheme.
+var providers = registry.New(fileScheme)
+
+// Register makes GetProvider use factory for URIs of scheme, case insensitive, replacing any earlier factory
+// of that scheme. Plain paths have the "file" scheme.
+func Register(scheme string, factory Factory) {
+	providers.Register(scheme, factory)
+}
+
+// RegisterMatcher makes GetProvider use factory for the URIs match accepts, for backends a scheme
+// cannot tell apart, like S3 HTTPS URLs among other HTTPS URLs. Matchers are tried before schemes,
+// the latest registered first. name only shows in errors.
+func RegisterMatcher(name string, match Matcher, factory Factory) {
+	providers.RegisterMatcher(name, match, factory)
+}
+
+// Schemes lists the registered schemes, sorted.
+func Schemes() []string {
+	return providers.Schemes()
+}
+
+// lookup returns the factory of uri, nil when none handles it.
+func lookup(uri string) Factory {
+	factory, _ := providers.Lookup(uri).(Factory)
+	return factory
+}
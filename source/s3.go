@@ -4,31 +4,80 @@ import (
 	sharedS3Internal "github.com/kennykarnama/video-color-palette-generator/shared/s3"
 	
 	"fmt"
-	"path/filepath"
+	"os"
+	"path"
 	"context"
+	"sync"
 )
 
+// DefaultS3DownloadDir is where S3Source downloads go when its Dir is empty.
+const DefaultS3DownloadDir = "/tmp"
+
 type S3Source struct {
-	Data *sharedS3Internal.S3URI
+	Data   *sharedS3Internal.S3URI
+	Client sharedS3Internal.Client
+	// Dir is where downloads go, DefaultS3DownloadDir when empty.
+	Dir string
+
+	mu sync.Mutex
+	// downloads are the files LocalURI created, the only ones Cleanup removes.
+	downloads map[string]bool
 }
 
+// NewS3SourceFromURI uses the default S3 client, see NewS3SourceWithClient.
 func NewS3SourceFromURI(uri string) (*S3Source, error) {
-	data, err := sharedS3Internal.ParseURL(uri)
+	return NewS3SourceWithClient(uri, sharedS3Internal.DefaultClient())
+}
+
+func NewS3SourceWithClient(uri string, client sharedS3Internal.Client) (*S3Source, error) {
+	data, err := sharedS3Internal.ParseURLFor(client, uri)
 	if err != nil {
 		return nil, fmt.Errorf("action=newS3SourceFromURI uri=%v err=%v", uri, err)
 	}
 	return &S3Source {
-		Data: data,
+		Data:   data,
+		Client: client,
 	}, nil
 }
 
+// LocalURI downloads the object to a new file of Dir. The file name never comes from the key,
+// which may hold "..", and concurrent downloads of one key do not share a file.
 func (s *S3Source) LocalURI(ctx context.Context, uri string) (string, error) {
-	localUri := filepath.Join("/tmp", s.Data.Key)
-	err := sharedS3Internal.Download(ctx, s.Data.Bucket, s.Data.Key, localUri)
+	dir := s.Dir
+	if dir == "" {
+		dir = DefaultS3DownloadDir
+	}
+	f, err := os.CreateTemp(dir, "source-*"+path.Ext(s.Data.Key))
+	if err != nil {
+		return "", fmt.Errorf("action=s3Source.LocalURI uri=%v err=%v", uri, err)
+	}
+	localUri := f.Name()
+	f.Close()
+	err = s.Client.Download(ctx, s.Data, localUri)
 	if err != nil {
+		os.Remove(localUri)
 		return "", fmt.Errorf("action=s3Source.LocalURI uri=%v target=%v err=%v", uri, localUri, err)
 	}
+	s.mu.Lock()
+	if s.downloads == nil {
+		s.downloads = map[string]bool{}
+	}
+	s.downloads[localUri] = true
+	s.mu.Unlock()
 	return localUri, nil
 }
 
-
+// Cleanup removes the file LocalURI downloaded to localURI. Any other path is left alone.
+func (s *S3Source) Cleanup(localURI string) error {
+	s.mu.Lock()
+	downloaded := s.downloads[localURI]
+	delete(s.downloads, localURI)
+	s.mu.Unlock()
+	if !downloaded {
+		return nil
+	}
+	if err := os.Remove(localURI); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("action=s3Source.Cleanup localURI=%v err=%v", localURI, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sharedS3Internal "github.com/kennykarnama/video-color-palette-generator/shared/s3"
+	"github.com/kennykarnama/video-color-palette-generator/shared/s3/s3test"
+)
+
+// useS3Client makes client the default S3 client for the rest of the test.
+func useS3Client(t *testing.T, client sharedS3Internal.Client) {
+	t.Helper()
+	prev := sharedS3Internal.DefaultClient()
+	sharedS3Internal.SetDefaultClient(client)
+	t.Cleanup(func() { sharedS3Internal.SetDefaultClient(prev) })
+}
+
+func TestS3SourceLocalURI(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "nested key", key: "2024/video.mp4"},
+		{name: "dot dot key", key: "../../var/task/video.mp4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := s3test.NewClient()
+			client.Put("videos", tt.key, testVideo)
+
+			dir := t.TempDir()
+			uri := "s3://videos/" + tt.key + "?region=eu-west-1"
+			s, err := NewS3SourceWithClient(uri, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Dir = dir
+			localURI, err := s.LocalURI(context.Background(), uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.Dir(localURI) != dir || filepath.Ext(localURI) != ".mp4" {
+				t.Errorf("LocalURI()=%v, want a .mp4 file directly in %v", localURI, dir)
+			}
+			got, err := os.ReadFile(localURI)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, testVideo) {
+				t.Errorf("downloaded %v bytes, want the %v bytes of the video", len(got), len(testVideo))
+			}
+			want := sharedS3Internal.S3URI{Region: "eu-west-1", Bucket: "videos", Key: tt.key}
+			if requests := client.Requests(); len(requests) != 1 || requests[0] != want {
+				t.Errorf("requests=%v, want %v", requests, want)
+			}
+
+			// a second download of the same key gets its own file
+			second, err := s.LocalURI(context.Background(), uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if second == localURI {
+				t.Errorf("both downloads went to %v", localURI)
+			}
+
+			if err := s.Cleanup(localURI); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(localURI); !os.IsNotExist(err) {
+				t.Errorf("Cleanup left %v, stat err=%v", localURI, err)
+			}
+			if _, err := os.Stat(second); err != nil {
+				t.Errorf("Cleanup of the first download removed the second, stat err=%v", err)
+			}
+			if err := s.Cleanup(localURI); err != nil {
+				t.Errorf("second Cleanup err=%v", err)
+			}
+
+			// files LocalURI did not create are left alone
+			other := filepath.Join(dir, "other.mp4")
+			if err := os.WriteFile(other, testVideo, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Cleanup(other); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(other); err != nil {
+				t.Errorf("Cleanup removed %v, stat err=%v", other, err)
+			}
+		})
+	}
+}
+
+func TestS3SourceLocalURIErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		client func() *s3test.Client
+	}{
+		{name: "missing object", client: s3test.NewClient},
+		{name: "client error", client: func() *s3test.Client {
+			client := s3test.NewClient()
+			client.Err = errors.New("AccessDenied")
+			return client
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewS3SourceWithClient("s3://videos/video.mp4", tt.client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Dir = t.TempDir()
+			if _, err := s.LocalURI(context.Background(), "s3://videos/video.mp4"); err == nil {
+				t.Fatal("LocalURI succeeded")
+			}
+			if files, _ := os.ReadDir(s.Dir); len(files) != 0 {
+				t.Errorf("failed download left %v files", len(files))
+			}
+		})
+	}
+}
+
+func TestGetProviderUsesDefaultS3Client(t *testing.T) {
+	client := s3test.NewClient()
+	useS3Client(t, client)
+
+	for _, uri := range []string{
+		"s3://videos/a.mp4",
+		"https://videos.s3.eu-west-1.amazonaws.com/a.mp4",
+		"https://s3.eu-west-1.amazonaws.com/videos/a.mp4",
+	} {
+		provider, err := GetProvider(uri)
+		if err != nil {
+			t.Fatalf("GetProvider(%v) err=%v", uri, err)
+		}
+		s, ok := provider.(*S3Source)
+		if !ok {
+			t.Fatalf("GetProvider(%v)=%T, want *S3Source", uri, provider)
+		}
+		if s.Client != client {
+			t.Errorf("GetProvider(%v) does not use the default client", uri)
+		}
+		if s.Data.Bucket != "videos" || s.Data.Key != "a.mp4" {
+			t.Errorf("GetProvider(%v) data=%+v", uri, s.Data)
+		}
+	}
+}
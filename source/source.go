@@ -6,4 +6,6 @@ import (
 
 type Provider interface {
 	LocalURI(ctx context.Context, sourceURL string) (string, error)
+	// Cleanup releases what LocalURI created for localURI, if anything.
+	Cleanup(localURI string) error
 }